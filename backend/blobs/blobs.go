@@ -0,0 +1,249 @@
+// Package blobs stores asset files by content hash so identical models and
+// textures are kept once. Every blob is reference-counted by the rows that
+// use it (asset_ref); blobs nobody references are removed by Collect.
+package blobs
+
+import (
+	"backend/storage"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Owner types recorded in asset_ref.
+const (
+	OwnerFurniture = "furniture"
+	OwnerRoom      = "room"
+	OwnerVariant   = "furniture_variant"
+	OwnerProject   = "project"
+)
+
+// Blob is a stored file identified by the SHA-256 of its contents.
+type Blob struct {
+	Hash string
+	Key  string
+	Size int64
+}
+
+// Ref links an owner row to a blob under the file name the owner knows it by,
+// e.g. "chair.mtl" or "textures/wood.png", so relative references between
+// OBJ, MTL and texture files can still be resolved.
+type Ref struct {
+	Name string
+	Hash string
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Store writes blobs to Files and records them in the asset_blob table.
+type Store struct {
+	DB    *sql.DB
+	Files storage.Storage
+}
+
+// Key returns the storage key of a blob. The extension of the first upload is
+// kept so the file is served with a sensible content type.
+func Key(hash, name string) string {
+	return "blobs/" + hash[:2] + "/" + hash + strings.ToLower(path.Ext(name))
+}
+
+// Put stores the contents of r unless a blob with the same hash exists and
+// returns the blob. A new blob starts unreferenced; attach it to its owner
+// with Attach before the next garbage collection.
+func (s *Store) Put(ctx context.Context, r io.Reader, name, contentType string) (Blob, error) {
+	// The key depends on the hash, so spool the upload before storing it.
+	tmp, err := os.CreateTemp("", "blob-*")
+	if err != nil {
+		return Blob{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	if err != nil {
+		return Blob{}, err
+	}
+	blob := Blob{Hash: hex.EncodeToString(hasher.Sum(nil)), Size: size}
+
+	err = s.DB.QueryRowContext(ctx, "SELECT key FROM asset_blob WHERE hash = $1", blob.Hash).Scan(&blob.Key)
+	if err == nil {
+		// Touch the blob so a collection running right now leaves it alone.
+		_, err = s.DB.ExecContext(ctx, "UPDATE asset_blob SET created_at = now() WHERE hash = $1 AND ref_count = 0", blob.Hash)
+		return blob, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Blob{}, err
+	}
+
+	blob.Key = Key(blob.Hash, name)
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Blob{}, err
+	}
+	if err := s.Files.Put(ctx, blob.Key, tmp, size, contentType); err != nil {
+		return Blob{}, fmt.Errorf("could not store %s: %w", name, err)
+	}
+	_, err = s.DB.ExecContext(ctx, `
+		INSERT INTO asset_blob (hash, key, size, content_type) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (hash) DO NOTHING`,
+		blob.Hash, blob.Key, blob.Size, contentType)
+	return blob, err
+}
+
+// Attach replaces the blobs referenced by an owner row. Reference counts are
+// maintained by triggers on asset_ref, so blobs the owner no longer uses
+// become collectable.
+func Attach(ctx context.Context, q Execer, ownerType string, ownerID int, refs []Ref) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM asset_ref WHERE owner_type = $1 AND owner_id = $2", ownerType, ownerID); err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := AddRef(ctx, q, ownerType, ownerID, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refs lists the blobs referenced by an owner row by file name.
+func Refs(ctx context.Context, q Execer, ownerType string, ownerID int) (map[string]Blob, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.name, b.hash, b.key, b.size
+		FROM asset_ref r JOIN asset_blob b ON b.hash = r.hash
+		WHERE r.owner_type = $1 AND r.owner_id = $2`, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	refs := map[string]Blob{}
+	for rows.Next() {
+		var name string
+		var b Blob
+		if err := rows.Scan(&name, &b.Hash, &b.Key, &b.Size); err != nil {
+			return nil, err
+		}
+		refs[name] = b
+	}
+	return refs, rows.Err()
+}
+
+// GCReport summarises a garbage collection run.
+type GCReport struct {
+	DryRun       bool     `json:"dry_run"`
+	Deleted      int      `json:"deleted"`
+	FreedBytes   int64    `json:"freed_bytes"`
+	Keys         []string `json:"keys"`
+	FailedDelete []string `json:"failed_delete,omitempty"`
+}
+
+// Collect removes blobs that have been unreferenced for longer than grace.
+// The grace period protects blobs uploaded moments ago whose owner row has
+// not been written yet.
+func (s *Store) Collect(ctx context.Context, grace time.Duration, dryRun bool) (GCReport, error) {
+	report := GCReport{DryRun: dryRun, Keys: []string{}}
+	cutoff := time.Now().Add(-grace)
+
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT hash, key, size FROM asset_blob WHERE ref_count <= 0 AND created_at < $1 ORDER BY created_at", cutoff)
+	if err != nil {
+		return report, err
+	}
+	var orphans []Blob
+	for rows.Next() {
+		var b Blob
+		if err := rows.Scan(&b.Hash, &b.Key, &b.Size); err != nil {
+			rows.Close()
+			return report, err
+		}
+		orphans = append(orphans, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, b := range orphans {
+		if !dryRun {
+			// Re-check under the delete so a blob referenced in the meantime survives.
+			result, err := s.DB.ExecContext(ctx,
+				"DELETE FROM asset_blob WHERE hash = $1 AND ref_count <= 0 AND created_at < $2", b.Hash, cutoff)
+			if err != nil {
+				return report, err
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				continue
+			}
+			if err := s.Files.Delete(ctx, b.Key); err != nil {
+				report.FailedDelete = append(report.FailedDelete, b.Key)
+				continue
+			}
+		}
+		report.Deleted++
+		report.FreedBytes += b.Size
+		report.Keys = append(report.Keys, b.Key)
+	}
+	return report, nil
+}
+
+// CollectEvery runs Collect on a fixed interval until ctx is cancelled.
+func (s *Store) CollectEvery(ctx context.Context, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Collect(ctx, grace, false)
+			if err != nil {
+				log.Printf("Asset garbage collection failed: %v", err)
+				continue
+			}
+			if report.Deleted > 0 || len(report.FailedDelete) > 0 {
+				log.Printf("Asset garbage collection removed %d blobs (%d bytes), %d could not be deleted",
+					report.Deleted, report.FreedBytes, len(report.FailedDelete))
+			}
+		}
+	}
+}
+
+// HashFromKey returns the content hash of a blob key, or false when key is
+// not a blob key.
+func HashFromKey(key string) (string, bool) {
+	key = storage.NormalizeKey(key)
+	if !strings.HasPrefix(key, "blobs/") {
+		return "", false
+	}
+	name := path.Base(key)
+	hash := strings.TrimSuffix(name, path.Ext(name))
+	if len(hash) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// AddRef adds or replaces a single reference of an owner row, leaving its
+// other references in place.
+func AddRef(ctx context.Context, q Execer, ownerType string, ownerID int, ref Ref) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO asset_ref (owner_type, owner_id, name, hash) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner_type, owner_id, name) DO UPDATE SET hash = EXCLUDED.hash`,
+		ownerType, ownerID, ref.Name, ref.Hash)
+	return err
+}
@@ -0,0 +1,84 @@
+// Package commands implements the maintenance subcommands of the server
+// binary, e.g. "go run main.go import -manifest items.csv -archive items.zip".
+package commands
+
+import (
+	"backend/blobs"
+	"backend/convert"
+	"backend/db"
+	"backend/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command runs with the arguments following its name.
+type command func(args []string) error
+
+var registry = map[string]command{
+	// convert converts furniture and room models to GLB, skipping those
+	// already converted unless -force is given.
+	"convert": backfill("convert", (*convert.Converter).Backfill,
+		"reconvert models that already have a GLB", "some models failed to convert"),
+	"gc":     gcCommand,
+	"import": importCommand,
+	// lod generates levels of detail for heavy catalog models, skipping
+	// items that already have them unless -force is given.
+	"lod": backfill("lod", (*convert.Converter).BackfillLODs,
+		"regenerate existing levels of detail", "some models failed to simplify"),
+	// previews renders previews for projects that have none.
+	"previews": backfill("previews", (*convert.Converter).BackfillPreviews,
+		"regenerate every project preview", "some previews failed to render"),
+	// thumbnails renders thumbnails for furniture and rooms that have none,
+	// or with -force every thumbnail, including uploaded ones.
+	"thumbnails": backfill("thumbnails", (*convert.Converter).BackfillThumbnails,
+		"regenerate all thumbnails, replacing existing ones", "some thumbnails failed to render"),
+}
+
+// Run executes the named subcommand. The database must be initialised.
+func Run(name string, args []string) error {
+	cmd, ok := registry[name]
+	if !ok {
+		names := make([]string, 0, len(registry))
+		for n := range registry {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q (available: %v)", name, names)
+	}
+	return cmd(args)
+}
+
+// backfill returns a command that runs one of the converter's backfills
+// and prints its report. usage describes the -force flag, which redoes
+// items already done; the command fails with the failure message when any
+// item did.
+func backfill(name string, run func(*convert.Converter, context.Context, bool) (convert.Report, error), usage, failure string) command {
+	return func(args []string) error {
+		flags := flag.NewFlagSet(name, flag.ContinueOnError)
+		force := flags.Bool("force", false, usage)
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+
+		cv := &convert.Converter{DB: db.DB, Blobs: &blobs.Store{DB: db.DB, Files: storage.Assets}}
+		report, err := run(cv, context.Background(), *force)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+		if report.Failed > 0 {
+			return errors.New(failure)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"backend/blobs"
+	"backend/db"
+	"backend/storage"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+)
+
+// gcCommand removes content-addressed asset files no row references any more.
+func gcCommand(args []string) error {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	grace := flags.Duration("grace", time.Hour, "keep unreferenced files younger than this")
+	dryRun := flags.Bool("dry-run", false, "list the files that would be removed")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	store := &blobs.Store{DB: db.DB, Files: storage.Assets}
+	report, err := store.Collect(context.Background(), *grace, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
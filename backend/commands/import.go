@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"archive/zip"
+	"backend/blobs"
+	"backend/convert"
+	"backend/db"
+	"backend/importer"
+	"backend/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importCommand bulk-imports a catalog manifest, printing the JSON report.
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	manifestPath := flags.String("manifest", "", "CSV or JSON manifest")
+	archivePath := flags.String("archive", "", "ZIP with the OBJ, texture and thumbnail files")
+	dryRun := flags.Bool("dry-run", false, "validate only")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *manifestPath == "" {
+		return errors.New("-manifest is required")
+	}
+
+	manifest, err := os.Open(*manifestPath)
+	if err != nil {
+		return err
+	}
+	defer manifest.Close()
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(*manifestPath)), ".")
+	rows, err := importer.ParseManifest(manifest, format)
+	if err != nil {
+		return err
+	}
+
+	var archive *zip.Reader
+	if *archivePath != "" {
+		rc, err := zip.OpenReader(*archivePath)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		archive = &rc.Reader
+	}
+
+	store := &blobs.Store{DB: db.DB, Files: storage.Assets}
+	im := importer.Importer{
+		DB:        db.DB,
+		Blobs:     store,
+		DryRun:    *dryRun,
+		Converter: &convert.Converter{DB: db.DB, Blobs: store},
+	}
+	report, err := im.Run(context.Background(), rows, archive)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return errors.New("some rows failed to import")
+	}
+	return nil
+}
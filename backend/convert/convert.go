@@ -0,0 +1,255 @@
+// Package convert turns the stored OBJ models of catalog items and rooms into
+// single binary glTF (GLB) files with their textures embedded.
+package convert
+
+import (
+	"backend/blobs"
+	"backend/mesh"
+	"backend/storage"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// GLBName is the asset_ref name of an owner's converted model.
+const GLBName = "model.glb"
+
+// tables maps the owner types that have models to their tables.
+var tables = map[string]string{
+	blobs.OwnerFurniture: "furniture",
+	blobs.OwnerRoom:      "room",
+}
+
+// Converter converts models and records the GLB in the owner row's
+// glb_file_path column.
+type Converter struct {
+	DB    *sql.DB
+	Blobs *blobs.Store
+}
+
+// Furniture converts the model of a catalog item and returns the GLB key.
+func (cv *Converter) Furniture(ctx context.Context, id int) (string, error) {
+	return cv.convert(ctx, blobs.OwnerFurniture, id)
+}
+
+// Room converts the model of a room and returns the GLB key.
+func (cv *Converter) Room(ctx context.Context, id int) (string, error) {
+	return cv.convert(ctx, blobs.OwnerRoom, id)
+}
+
+func (cv *Converter) convert(ctx context.Context, ownerType string, id int) (string, error) {
+	table := tables[ownerType]
+	var objPath, texturePath string
+	err := cv.DB.QueryRowContext(ctx,
+		"SELECT obj_file_path, COALESCE(texture_path, '') FROM "+table+" WHERE id = $1", id).
+		Scan(&objPath, &texturePath)
+	if err != nil {
+		return "", err
+	}
+	refs, err := blobs.Refs(ctx, cv.DB, ownerType, id)
+	if err != nil {
+		return "", err
+	}
+
+	src := &sources{files: cv.Blobs.Files, refs: refs}
+	model, opts, err := src.load(ctx, objPath, texturePath)
+	if err != nil {
+		return "", err
+	}
+	var glb bytes.Buffer
+	if err := mesh.WriteGLB(&glb, model, opts); err != nil {
+		return "", err
+	}
+	blob, err := cv.Blobs.Put(ctx, &glb, GLBName, "model/gltf-binary")
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := cv.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	if err := blobs.AddRef(ctx, tx, ownerType, id, blobs.Ref{Name: GLBName, Hash: blob.Hash}); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET glb_file_path = $1 WHERE id = $2", blob.Key, id); err != nil {
+		return "", err
+	}
+	return blob.Key, tx.Commit()
+}
+
+// Report summarises a backfill run.
+type Report struct {
+	Converted int      `json:"converted"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Backfill converts every furniture item and room without a GLB, or all of
+// them when force is set. Failures are reported and do not stop the run.
+func (cv *Converter) Backfill(ctx context.Context, force bool) (Report, error) {
+	var report Report
+	for _, ownerType := range []string{blobs.OwnerFurniture, blobs.OwnerRoom} {
+		query := "SELECT id FROM " + tables[ownerType]
+		if !force {
+			query += " WHERE glb_file_path = ''"
+		}
+		rows, err := cv.DB.QueryContext(ctx, query+" ORDER BY id")
+		if err != nil {
+			return report, err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return report, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, err
+		}
+
+		for _, id := range ids {
+			if _, err := cv.convert(ctx, ownerType, id); err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %d: %v", tables[ownerType], id, err))
+				continue
+			}
+			report.Converted++
+		}
+	}
+	return report, nil
+}
+
+// sources resolves the files an OBJ refers to. Content-addressed models are
+// looked up through the owner's asset_ref names; older models through paths
+// relative to the referencing file's key.
+type sources struct {
+	files storage.Storage
+	refs  map[string]blobs.Blob
+}
+
+// file is a stored file together with the name it was uploaded under.
+type file struct {
+	key  string
+	name string
+}
+
+// resolve finds the file ref refers to from the file from.
+func (s *sources) resolve(from file, ref string) file {
+	ref = strings.ReplaceAll(ref, "\\", "/")
+	for _, name := range []string{path.Join(path.Dir(from.name), ref), path.Base(ref)} {
+		if blob, ok := s.refs[name]; ok {
+			return file{key: blob.Key, name: name}
+		}
+	}
+	return file{key: path.Join(path.Dir(from.key), ref), name: path.Join(path.Dir(from.name), ref)}
+}
+
+// fromKey describes a stored file known by its key.
+func (s *sources) fromKey(key string) file {
+	key = storage.NormalizeKey(key)
+	for name, blob := range s.refs {
+		if blob.Key == key {
+			return file{key: key, name: name}
+		}
+	}
+	return file{key: key, name: path.Base(key)}
+}
+
+func (s *sources) read(ctx context.Context, f file) ([]byte, error) {
+	rc, err := s.files.Open(ctx, f.key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// image reads a texture, returning false for formats glTF cannot embed.
+func (s *sources) image(ctx context.Context, f file) (mesh.Image, bool, error) {
+	data, err := s.read(ctx, f)
+	if err != nil {
+		return mesh.Image{}, false, err
+	}
+	switch mimeType := http.DetectContentType(data); mimeType {
+	case "image/png", "image/jpeg":
+		return mesh.Image{Data: data, MimeType: mimeType}, true, nil
+	}
+	log.Printf("Skipping texture %s: only PNG and JPEG can be embedded in GLB", f.name)
+	return mesh.Image{}, false, nil
+}
+
+// load reads the OBJ at objPath with its materials and textures. A texture
+// path replaces the materials' own diffuse maps, as it does in the editor.
+func (s *sources) load(ctx context.Context, objPath, texturePath string) (*mesh.Mesh, mesh.GLBOptions, error) {
+	var opts mesh.GLBOptions
+	obj := s.fromKey(objPath)
+	data, err := s.read(ctx, obj)
+	if err != nil {
+		return nil, opts, err
+	}
+	model, err := mesh.ParseOBJ(bytes.NewReader(data))
+	if err != nil {
+		return nil, opts, fmt.Errorf("%s: %w", obj.name, err)
+	}
+
+	opts = mesh.GLBOptions{Materials: map[string]*mesh.Material{}, Textures: map[string]mesh.Image{}}
+	if texturePath != "" {
+		img, ok, err := s.image(ctx, s.fromKey(texturePath))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, opts, err
+		}
+		if ok {
+			opts.Texture = &img
+		}
+	}
+	for _, lib := range model.MaterialLibs {
+		mtl := s.resolve(obj, lib)
+		data, err := s.read(ctx, mtl)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Material library %s of %s is missing, using default materials", lib, obj.name)
+			continue
+		}
+		if err != nil {
+			return nil, opts, err
+		}
+		materials, err := mesh.ParseMTL(bytes.NewReader(data))
+		if err != nil {
+			return nil, opts, fmt.Errorf("%s: %w", mtl.name, err)
+		}
+		for name, mat := range materials {
+			opts.Materials[name] = mat
+			if mat.DiffuseMap == "" || opts.Texture != nil {
+				continue
+			}
+			if _, done := opts.Textures[mat.DiffuseMap]; done {
+				continue
+			}
+			img, ok, err := s.image(ctx, s.resolve(mtl, mat.DiffuseMap))
+			if errors.Is(err, storage.ErrNotFound) {
+				log.Printf("Texture %s of %s is missing", mat.DiffuseMap, mtl.name)
+				continue
+			}
+			if err != nil {
+				return nil, opts, err
+			}
+			if ok {
+				opts.Textures[mat.DiffuseMap] = img
+			}
+		}
+	}
+
+	return model, opts, nil
+}
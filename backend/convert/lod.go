@@ -0,0 +1,166 @@
+package convert
+
+import (
+	"backend/blobs"
+	"backend/mesh"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+)
+
+// MinLODFaces is the face count below which a model gets no LODs: it is
+// cheap enough to draw at full detail everywhere.
+const MinLODFaces = 5000
+
+// lodLevels are the generated levels of detail: each keeps a fraction of the
+// full model's faces and is meant for viewing distances beyond a multiple of
+// the item's largest dimension.
+var lodLevels = []struct {
+	fraction float64
+	distance float64
+}{
+	{0.25, 4},
+	{0.08, 10},
+	{0.02, 25},
+}
+
+// minLODFaces keeps very coarse levels recognisable.
+const minLODFaces = 150
+
+// LOD is a generated level of detail of a catalog item.
+type LOD struct {
+	Level       int
+	FaceCount   int
+	Key         string
+	MinDistance float64 // metres from the camera at which to switch to this level
+}
+
+// FurnitureLODs simplifies the model of a catalog item into up to three
+// GLB levels of detail and records them in furniture_lod, replacing earlier
+// ones. Models under MinLODFaces get none.
+func (cv *Converter) FurnitureLODs(ctx context.Context, id int) ([]LOD, error) {
+	var objPath, texturePath string
+	var width, depth, height float64
+	err := cv.DB.QueryRowContext(ctx, `
+		SELECT obj_file_path, COALESCE(texture_path, ''), width, depth, height
+		FROM furniture WHERE id = $1`, id).
+		Scan(&objPath, &texturePath, &width, &depth, &height)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := blobs.Refs(ctx, cv.DB, blobs.OwnerFurniture, id)
+	if err != nil {
+		return nil, err
+	}
+	src := &sources{files: cv.Blobs.Files, refs: refs}
+	model, opts, err := src.load(ctx, objPath, texturePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var lods []LOD
+	var blobRefs []blobs.Ref
+	if full := len(model.Triangles); full >= MinLODFaces {
+		size := math.Max(width, math.Max(depth, height))
+		if size <= 0 {
+			size = 1
+		}
+		previous := full
+		for _, level := range lodLevels {
+			target := int(float64(full) * level.fraction)
+			if target < minLODFaces {
+				target = minLODFaces
+			}
+			simplified := mesh.Simplify(model, target)
+			faces := len(simplified.Triangles)
+			// Skip levels that would barely differ from the previous one.
+			if faces == 0 || float64(faces) > 0.8*float64(previous) {
+				continue
+			}
+			previous = faces
+
+			var glb bytes.Buffer
+			if err := mesh.WriteGLB(&glb, simplified, opts); err != nil {
+				return nil, err
+			}
+			name := fmt.Sprintf("lod%d.glb", len(lods)+1)
+			blob, err := cv.Blobs.Put(ctx, &glb, name, "model/gltf-binary")
+			if err != nil {
+				return nil, err
+			}
+			lods = append(lods, LOD{
+				Level:       len(lods) + 1,
+				FaceCount:   faces,
+				Key:         blob.Key,
+				MinDistance: math.Round(size*level.distance*10) / 10,
+			})
+			blobRefs = append(blobRefs, blobs.Ref{Name: name, Hash: blob.Hash})
+		}
+	}
+
+	tx, err := cv.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM furniture_lod WHERE furniture_id = $1", id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM asset_ref WHERE owner_type = $1 AND owner_id = $2 AND name LIKE 'lod%.glb'",
+		blobs.OwnerFurniture, id); err != nil {
+		return nil, err
+	}
+	for i, lod := range lods {
+		if err := blobs.AddRef(ctx, tx, blobs.OwnerFurniture, id, blobRefs[i]); err != nil {
+			return nil, err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO furniture_lod (furniture_id, level, face_count, glb_file_path, min_distance)
+			VALUES ($1, $2, $3, $4, $5)`,
+			id, lod.Level, lod.FaceCount, lod.Key, lod.MinDistance)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lods, tx.Commit()
+}
+
+// BackfillLODs generates levels of detail for every catalog item at or above
+// MinLODFaces that has none yet, or for all of them when force is set. Items
+// uploaded before face counts were recorded are checked as well.
+func (cv *Converter) BackfillLODs(ctx context.Context, force bool) (Report, error) {
+	var report Report
+	query := `SELECT id FROM furniture f WHERE (face_count >= $1 OR face_count = 0)`
+	if !force {
+		query += ` AND NOT EXISTS (SELECT 1 FROM furniture_lod l WHERE l.furniture_id = f.id)`
+	}
+	rows, err := cv.DB.QueryContext(ctx, query+" ORDER BY id", MinLODFaces)
+	if err != nil {
+		return report, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return report, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, id := range ids {
+		if _, err := cv.FurnitureLODs(ctx, id); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("furniture %d: %v", id, err))
+			continue
+		}
+		report.Converted++
+	}
+	return report, nil
+}
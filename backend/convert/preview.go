@@ -0,0 +1,197 @@
+package convert
+
+import (
+	"backend/blobs"
+	"backend/mesh"
+	"backend/render"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+)
+
+// PreviewName is the asset_ref name of a rendered project preview.
+const PreviewName = "preview.png"
+
+// ErrNothingToRender is returned for projects with no room and no furniture.
+var ErrNothingToRender = errors.New("project has nothing to render")
+
+// ProjectPreview renders the room of a project with its placed furniture,
+// stores the image as the project's preview and returns its key. A chosen
+// variant's texture replaces the item's default one, as in the editor.
+func (cv *Converter) ProjectPreview(ctx context.Context, projectID int) (string, error) {
+	roomID, placements, err := cv.projectLayout(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []render.Part
+	if roomID != 0 {
+		room, err := cv.loadScene(ctx, blobs.OwnerRoom, roomID, "")
+		if err != nil {
+			return "", fmt.Errorf("room %d: %w", roomID, err)
+		}
+		parts = append(parts, render.Part{Scene: room, Transform: mesh.Identity})
+	}
+
+	// Items placed several times are loaded once.
+	loaded := map[modelKey]render.Scene{}
+	for _, p := range placements {
+		key := modelKey{p.furnitureID, p.texture}
+		scene, ok := loaded[key]
+		if !ok {
+			scene, err = cv.loadScene(ctx, blobs.OwnerFurniture, p.furnitureID, p.texture)
+			if err != nil {
+				return "", fmt.Errorf("furniture %d: %w", p.furnitureID, err)
+			}
+			loaded[key] = scene
+		}
+		parts = append(parts, render.Part{Scene: scene, Transform: p.transform})
+	}
+
+	scene := render.Compose(parts)
+	if len(scene.Mesh.Triangles) == 0 {
+		return "", ErrNothingToRender
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, render.Render(scene, render.ProjectView)); err != nil {
+		return "", err
+	}
+	blob, err := cv.Blobs.Put(ctx, &buf, PreviewName, "image/png")
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := cv.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	if err := blobs.AddRef(ctx, tx, blobs.OwnerProject, projectID, blobs.Ref{Name: PreviewName, Hash: blob.Hash}); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE projects SET preview_path = $1 WHERE id = $2", blob.Key, projectID); err != nil {
+		return "", err
+	}
+	return blob.Key, tx.Commit()
+}
+
+// placement is a furniture item placed in a project, with the texture of
+// its chosen variant, if any.
+type placement struct {
+	furnitureID int
+	name        string
+	transform   mesh.Transform
+	texture     string
+}
+
+// modelKey identifies a furniture model as loaded with a variant texture.
+type modelKey struct {
+	furnitureID int
+	texture     string
+}
+
+// projectLayout reads the room of a project, 0 for none, and its placed
+// furniture in placement order.
+func (cv *Converter) projectLayout(ctx context.Context, projectID int) (int, []placement, error) {
+	var roomID int
+	err := cv.DB.QueryRowContext(ctx,
+		"SELECT COALESCE(room_layout_id, 0) FROM projects WHERE id = $1", projectID).Scan(&roomID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := cv.DB.QueryContext(ctx, `
+		SELECT pf.furniture_id, COALESCE(f.name, ''), pf.x, pf.y, pf.z,
+		       pf.rotation_x, pf.rotation, pf.rotation_z, pf.scale_x, pf.scale_y, pf.scale_z, COALESCE(v.texture_path, '')
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		LEFT JOIN furniture_variant v ON pf.variant_id = v.id
+		WHERE pf.project_id = $1
+		ORDER BY pf.id`, projectID)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+	var placements []placement
+	for rows.Next() {
+		var p placement
+		t := &p.transform
+		err := rows.Scan(&p.furnitureID, &p.name, &t.Position.X, &t.Position.Y, &t.Position.Z,
+			&t.Rotation.X, &t.Rotation.Y, &t.Rotation.Z, &t.Scale.X, &t.Scale.Y, &t.Scale.Z, &p.texture)
+		if err != nil {
+			return 0, nil, err
+		}
+		placements = append(placements, p)
+	}
+	return roomID, placements, rows.Err()
+}
+
+// loadModel reads the model of a furniture item or room with its materials
+// and textures. A non-empty texturePath replaces the owner's own texture.
+func (cv *Converter) loadModel(ctx context.Context, ownerType string, id int, texturePath string) (*mesh.Mesh, mesh.GLBOptions, error) {
+	var objPath, ownTexture string
+	err := cv.DB.QueryRowContext(ctx,
+		"SELECT obj_file_path, COALESCE(texture_path, '') FROM "+tables[ownerType]+" WHERE id = $1", id).
+		Scan(&objPath, &ownTexture)
+	if err != nil {
+		return nil, mesh.GLBOptions{}, err
+	}
+	if texturePath == "" {
+		texturePath = ownTexture
+	}
+	refs, err := blobs.Refs(ctx, cv.DB, ownerType, id)
+	if err != nil {
+		return nil, mesh.GLBOptions{}, err
+	}
+	src := &sources{files: cv.Blobs.Files, refs: refs}
+	return src.load(ctx, objPath, texturePath)
+}
+
+// loadScene reads the model of a furniture item or room ready for rendering.
+func (cv *Converter) loadScene(ctx context.Context, ownerType string, id int, texturePath string) (render.Scene, error) {
+	model, opts, err := cv.loadModel(ctx, ownerType, id, texturePath)
+	if err != nil {
+		return render.Scene{}, err
+	}
+	return scene(model, opts), nil
+}
+
+// BackfillPreviews renders previews for every project without one, or for
+// all of them when force is set. Failures are reported and do not stop the
+// run.
+func (cv *Converter) BackfillPreviews(ctx context.Context, force bool) (Report, error) {
+	var report Report
+	query := "SELECT id FROM projects"
+	if !force {
+		query += " WHERE preview_path = ''"
+	}
+	rows, err := cv.DB.QueryContext(ctx, query+" ORDER BY id")
+	if err != nil {
+		return report, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return report, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, id := range ids {
+		if _, err := cv.ProjectPreview(ctx, id); err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, fmt.Sprintf("project %d: %v", id, err))
+			continue
+		}
+		report.Converted++
+	}
+	return report, nil
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"backend/blobs"
+	"backend/mesh"
+	"context"
+	"fmt"
+	"io"
+)
+
+// ProjectScene writes the room of a project and its placed furniture as one
+// GLB scene, each model a node moved, turned and scaled as the editor
+// places it.
+// Models placed several times are stored once, and a chosen variant's
+// texture replaces the item's default one.
+func (cv *Converter) ProjectScene(ctx context.Context, projectID int, w io.Writer) error {
+	roomID, placements, err := cv.projectLayout(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	var models []mesh.SceneModel
+	var nodes []mesh.SceneNode
+	if roomID != 0 {
+		var name string
+		if err := cv.DB.QueryRowContext(ctx, "SELECT COALESCE(name, '') FROM room WHERE id = $1", roomID).Scan(&name); err != nil {
+			return fmt.Errorf("room %d: %w", roomID, err)
+		}
+		model, opts, err := cv.loadModel(ctx, blobs.OwnerRoom, roomID, "")
+		if err != nil {
+			return fmt.Errorf("room %d: %w", roomID, err)
+		}
+		models = append(models, mesh.SceneModel{Name: name, Mesh: model, Options: opts})
+		nodes = append(nodes, mesh.SceneNode{Name: name})
+	}
+
+	loaded := map[modelKey]int{}
+	for _, p := range placements {
+		key := modelKey{p.furnitureID, p.texture}
+		index, ok := loaded[key]
+		if !ok {
+			model, opts, err := cv.loadModel(ctx, blobs.OwnerFurniture, p.furnitureID, p.texture)
+			if err != nil {
+				return fmt.Errorf("furniture %d: %w", p.furnitureID, err)
+			}
+			index = len(models)
+			models = append(models, mesh.SceneModel{Name: p.name, Mesh: model, Options: opts})
+			loaded[key] = index
+		}
+		nodes = append(nodes, mesh.SceneNode{
+			Name:        p.name,
+			Model:       index,
+			Translation: p.transform.Position,
+			Rotation:    p.transform.Rotation.Quaternion(),
+			Scale:       p.transform.Scale,
+		})
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("project has nothing to export")
+	}
+	return mesh.WriteSceneGLB(w, models, nodes)
+}
@@ -0,0 +1,129 @@
+package convert
+
+import (
+	"backend/blobs"
+	"backend/mesh"
+	"backend/render"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+)
+
+// ThumbnailName is the asset_ref name of a rendered thumbnail. Uploaded
+// files are referenced by their base name, so none can take it.
+const ThumbnailName = "rendered/thumbnail.png"
+
+// FurnitureThumbnail renders a preview of a catalog item, stores it as the
+// item's thumbnail and returns its key.
+func (cv *Converter) FurnitureThumbnail(ctx context.Context, id int) (string, error) {
+	return cv.thumbnail(ctx, blobs.OwnerFurniture, id)
+}
+
+// RoomThumbnail renders a preview of a room, stores it as the room's
+// thumbnail and returns its key.
+func (cv *Converter) RoomThumbnail(ctx context.Context, id int) (string, error) {
+	return cv.thumbnail(ctx, blobs.OwnerRoom, id)
+}
+
+func (cv *Converter) thumbnail(ctx context.Context, ownerType string, id int) (string, error) {
+	scene, err := cv.loadScene(ctx, ownerType, id, "")
+	if err != nil {
+		return "", err
+	}
+	if len(scene.Mesh.Triangles) == 0 {
+		return "", fmt.Errorf("model has no faces to render")
+	}
+
+	view := render.ProductView
+	if ownerType == blobs.OwnerRoom {
+		view = render.RoomView
+	}
+	img := render.Render(scene, view)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	blob, err := cv.Blobs.Put(ctx, &buf, ThumbnailName, "image/png")
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := cv.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	if err := blobs.AddRef(ctx, tx, ownerType, id, blobs.Ref{Name: ThumbnailName, Hash: blob.Hash}); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE "+tables[ownerType]+" SET thumbnail_path = $1 WHERE id = $2", blob.Key, id); err != nil {
+		return "", err
+	}
+	return blob.Key, tx.Commit()
+}
+
+// scene decodes the textures gathered for the GLB so the renderer can
+// sample them. Textures that fail to decode fall back to material colours.
+func scene(model *mesh.Mesh, opts mesh.GLBOptions) render.Scene {
+	s := render.Scene{Mesh: model, Materials: opts.Materials, Textures: map[string]image.Image{}}
+	if opts.Texture != nil {
+		if img, _, err := image.Decode(bytes.NewReader(opts.Texture.Data)); err == nil {
+			s.Texture = img
+		} else {
+			log.Printf("Texture could not be decoded for the thumbnail: %v", err)
+		}
+	}
+	for name, data := range opts.Textures {
+		img, _, err := image.Decode(bytes.NewReader(data.Data))
+		if err != nil {
+			log.Printf("Texture %s could not be decoded for the thumbnail: %v", name, err)
+			continue
+		}
+		s.Textures[name] = img
+	}
+	return s
+}
+
+// BackfillThumbnails renders thumbnails for every furniture item and room
+// without one, or for all of them when force is set, replacing uploaded
+// images too. Failures are reported and do not stop the run.
+func (cv *Converter) BackfillThumbnails(ctx context.Context, force bool) (Report, error) {
+	var report Report
+	for _, ownerType := range []string{blobs.OwnerFurniture, blobs.OwnerRoom} {
+		query := "SELECT id FROM " + tables[ownerType]
+		if !force {
+			query += " WHERE COALESCE(thumbnail_path, '') = ''"
+		}
+		rows, err := cv.DB.QueryContext(ctx, query+" ORDER BY id")
+		if err != nil {
+			return report, err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return report, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, err
+		}
+
+		for _, id := range ids {
+			if _, err := cv.thumbnail(ctx, ownerType, id); err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %d: %v", tables[ownerType], id, err))
+				continue
+			}
+			report.Converted++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,299 @@
+package floorplan
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Point is a position on a drawing, in drawing units.
+type Point struct {
+	X, Y float64
+}
+
+// Path is a polyline of a drawing. Curves are flattened into short straight
+// segments while parsing.
+type Path struct {
+	Points []Point
+	Closed bool
+	Layer  string
+}
+
+// Drawing is the linework read from a CAD export.
+type Drawing struct {
+	Format string // "svg" or "dxf"
+	Paths  []Path
+	// Unit is the length of a drawing unit in metres, or 0 when the file does
+	// not say.
+	Unit float64
+	// YUp is set when Y grows upwards on the sheet, as in DXF. SVG Y grows
+	// downwards.
+	YUp bool
+
+	points int
+}
+
+// maxDrawingPoints bounds the linework read from a single file.
+const maxDrawingPoints = 500000
+
+func (d *Drawing) add(p Path) error {
+	if len(p.Points) < 2 {
+		return nil
+	}
+	for _, pt := range p.Points {
+		if !finite(pt.X, pt.Y) {
+			return errors.New("drawing has coordinates out of range")
+		}
+	}
+	d.points += len(p.Points)
+	if d.points > maxDrawingPoints {
+		return fmt.Errorf("drawing has more than %d points", maxDrawingPoints)
+	}
+	d.Paths = append(d.Paths, p)
+	return nil
+}
+
+// finite reports whether none of the values is NaN or infinite.
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// arcSegments returns how many straight segments an arc turning angle
+// radians is flattened into: one per sixteenth of a turn, and never more
+// than a full circle needs.
+func arcSegments(angle float64) int {
+	return int(math.Min(16, math.Ceil(math.Abs(angle)/(math.Pi/8))))
+}
+
+// unitLengths are the drawing units accepted for calibration, in metres.
+var unitLengths = map[string]float64{
+	"mm": 0.001,
+	"cm": 0.01,
+	"dm": 0.1,
+	"m":  1,
+	"in": 0.0254,
+	"ft": 0.3048,
+}
+
+// UnitLength returns the length in metres of a named drawing unit (mm, cm,
+// dm, m, in or ft).
+func UnitLength(name string) (float64, bool) {
+	length, ok := unitLengths[strings.ToLower(name)]
+	return length, ok
+}
+
+// ImportOptions control how a drawing is turned into a floor plan.
+type ImportOptions struct {
+	// Scale is the length of a drawing unit in metres. When 0, the scale
+	// comes from the reference distance, then Units, then the drawing.
+	Scale float64
+	// RefFrom and RefTo are two points on the drawing RefLength metres apart.
+	RefFrom, RefTo Point
+	RefLength      float64
+	Units          string
+	// Layers limits the import to paths on these layers; all when empty.
+	Layers []string
+	// DoubleWalls is set for drawings showing both faces of every wall. The
+	// room then follows the inner line and the wall thickness is measured
+	// from the gap between the two.
+	DoubleWalls bool
+	WallHeight  float64
+}
+
+// scale works out the drawing unit in metres.
+func (opts ImportOptions) scale(d *Drawing) (float64, error) {
+	switch {
+	case opts.Scale < 0:
+		return 0, errors.New("scale must be positive")
+	case opts.Scale > 0:
+		return opts.Scale, nil
+	case opts.RefLength < 0:
+		return 0, errors.New("reference length must be positive")
+	case opts.RefLength > 0:
+		drawn := math.Hypot(opts.RefTo.X-opts.RefFrom.X, opts.RefTo.Y-opts.RefFrom.Y)
+		if drawn == 0 {
+			return 0, errors.New("reference points must be apart")
+		}
+		return opts.RefLength / drawn, nil
+	case opts.Units != "":
+		length, ok := UnitLength(opts.Units)
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q", opts.Units)
+		}
+		return length, nil
+	case d.Unit > 0:
+		return d.Unit, nil
+	}
+	return 0, errors.New("the drawing does not give its units; set a scale, units or a reference length")
+}
+
+// Import finds the room outline on a drawing and returns it as a normalized
+// floor plan with its lowest corner at the origin, together with the scale
+// used. The outline is the largest closed loop of linework; open lines
+// meeting end to end, such as DXF LINE entities, are joined into loops
+// first.
+func Import(d *Drawing, opts ImportOptions) (models.FloorPlan, float64, error) {
+	var plan models.FloorPlan
+	scale, err := opts.scale(d)
+	if err != nil {
+		return plan, 0, err
+	}
+
+	layers := map[string]bool{}
+	for _, layer := range opts.Layers {
+		layers[strings.ToLower(layer)] = true
+	}
+	var paths []Path
+	for _, p := range d.Paths {
+		if len(layers) == 0 || layers[strings.ToLower(p.Layer)] {
+			paths = append(paths, p)
+		}
+	}
+
+	// Endpoints closer than a millimetre are taken to meet.
+	var outlines [][]models.FloorPoint
+	for _, loop := range closedLoops(paths, 0.001/scale) {
+		outline := make([]models.FloorPoint, len(loop))
+		for i, p := range loop {
+			outline[i] = models.FloorPoint{X: p.X * scale, Z: p.Y * scale}
+			if d.YUp {
+				outline[i].Z = -outline[i].Z
+			}
+		}
+		if Area(outline) >= 0.1 {
+			outlines = append(outlines, outline)
+		}
+	}
+	if len(outlines) == 0 {
+		return plan, 0, errors.New("no closed wall outline found on the drawing")
+	}
+	sort.SliceStable(outlines, func(i, j int) bool { return Area(outlines[i]) > Area(outlines[j]) })
+
+	plan.Floor = outlines[0]
+	if opts.DoubleWalls {
+		outer := outlines[0]
+		var inner []models.FloorPoint
+		for _, outline := range outlines[1:] {
+			if insideOutline(outer, outline) {
+				inner = outline
+				break
+			}
+		}
+		if inner == nil {
+			return plan, 0, errors.New("no inner wall line found inside the outline")
+		}
+		plan.Floor = inner
+		// The band between the lines is roughly perimeter x thickness.
+		plan.WallThickness = math.Max(0.01, math.Min(2, (Area(outer)-Area(inner))/perimeter(inner)))
+	}
+
+	lo, _ := Bounds(plan.Floor)
+	for i := range plan.Floor {
+		plan.Floor[i].X -= lo.X
+		plan.Floor[i].Z -= lo.Z
+	}
+	plan.WallHeight = opts.WallHeight
+	plan, err = Normalize(plan)
+	return plan, scale, err
+}
+
+// closedLoops returns the closed paths and the loops formed by open paths
+// whose ends meet within tol, without the repeated closing point.
+func closedLoops(paths []Path, tol float64) [][]Point {
+	key := func(p Point) [2]int64 {
+		return [2]int64{int64(math.Round(p.X / tol)), int64(math.Round(p.Y / tol))}
+	}
+	type chain struct {
+		points []Point
+		used   bool
+	}
+
+	var loops [][]Point
+	var chains []*chain
+	ends := map[[2]int64][]*chain{}
+	for _, p := range paths {
+		if p.Closed {
+			loops = append(loops, p.Points)
+			continue
+		}
+		ch := &chain{points: p.Points}
+		chains = append(chains, ch)
+		first, last := key(p.Points[0]), key(p.Points[len(p.Points)-1])
+		ends[first] = append(ends[first], ch)
+		if last != first {
+			ends[last] = append(ends[last], ch)
+		}
+	}
+
+	for _, start := range chains {
+		if start.used {
+			continue
+		}
+		start.used = true
+		loop := append([]Point(nil), start.points...)
+		for key(loop[len(loop)-1]) != key(loop[0]) {
+			end := key(loop[len(loop)-1])
+			var next *chain
+			for _, ch := range ends[end] {
+				if !ch.used {
+					next = ch
+					break
+				}
+			}
+			if next == nil {
+				break
+			}
+			next.used = true
+			points := next.points
+			if key(points[0]) != end {
+				points = reversedPoints(points)
+			}
+			loop = append(loop, points[1:]...)
+		}
+		if len(loop) > 3 && key(loop[len(loop)-1]) == key(loop[0]) {
+			loops = append(loops, loop[:len(loop)-1])
+		}
+	}
+	return loops
+}
+
+func reversedPoints(points []Point) []Point {
+	reversed := make([]Point, len(points))
+	for i, p := range points {
+		reversed[len(points)-1-i] = p
+	}
+	return reversed
+}
+
+func perimeter(floor []models.FloorPoint) float64 {
+	sum := 0.0
+	for i, p := range floor {
+		sum += distance(p, floor[(i+1)%len(floor)])
+	}
+	return sum
+}
+
+// insideOutline reports whether every corner of inner lies inside outer.
+func insideOutline(outer, inner []models.FloorPoint) bool {
+	for _, p := range inner {
+		inside := false
+		for i, a := range outer {
+			b := outer[(i+1)%len(outer)]
+			if (a.Z > p.Z) != (b.Z > p.Z) && p.X < a.X+(p.Z-a.Z)*(b.X-a.X)/(b.Z-a.Z) {
+				inside = !inside
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,271 @@
+package floorplan
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// dxfUnits maps $INSUNITS codes to metres.
+var dxfUnits = map[int]float64{
+	1:  0.0254, // inches
+	2:  0.3048, // feet
+	4:  0.001,  // millimetres
+	5:  0.01,   // centimetres
+	6:  1,      // metres
+	14: 0.1,    // decimetres
+}
+
+// dxfVertex is a polyline corner. A non-zero bulge makes the segment to the
+// next corner an arc: the tangent of a quarter of its angle, positive when
+// it turns counter-clockwise.
+type dxfVertex struct {
+	p     Point
+	bulge float64
+}
+
+// dxfEntity collects the group codes of the entity being read.
+type dxfEntity struct {
+	kind     string
+	layer    string
+	flags    int
+	vertices []dxfVertex
+	start    Point
+	end      Point
+	mirrored bool // extrusion direction (0, 0, -1)
+}
+
+// ParseDXF reads the linework of an ASCII DXF drawing: LWPOLYLINE, POLYLINE
+// and LINE entities of the ENTITIES section, on their layers. Bulged
+// polyline segments are flattened into arcs. Blocks are not expanded. The
+// unit comes from the $INSUNITS header variable when set.
+func ParseDXF(r io.Reader) (*Drawing, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(18); bytes.HasPrefix(head, []byte("AutoCAD Binary DXF")) {
+		return nil, errors.New("binary DXF is not supported; export as ASCII DXF")
+	}
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	d := &Drawing{Format: "dxf", YUp: true}
+	var section, variable string
+	var entity, polyline *dxfEntity
+	lineNo := 0
+
+	finish := func(e *dxfEntity) error {
+		if e == nil {
+			return nil
+		}
+		switch e.kind {
+		case "LINE":
+			return d.add(Path{Points: []Point{e.start, e.end}, Layer: e.layer})
+		case "LWPOLYLINE", "POLYLINE":
+			// Flags 16 and 64 mark polygon meshes, whose vertices are not
+			// a path.
+			if len(e.vertices) < 2 || e.flags&(16|64) != 0 {
+				return nil
+			}
+			closed := e.flags&1 != 0
+			points := bulgePath(e.vertices, closed)
+			if e.mirrored {
+				for i := range points {
+					points[i].X = -points[i].X
+				}
+			}
+			return d.add(Path{Points: points, Closed: closed, Layer: e.layer})
+		}
+		return nil
+	}
+	// finishCurrent ends the entity being read, or the polyline its vertices
+	// belong to when SEQEND is missing.
+	finishCurrent := func() error {
+		if polyline != nil {
+			entity = polyline
+		}
+		err := finish(entity)
+		entity, polyline = nil, nil
+		return err
+	}
+
+	for {
+		if !scanner.Scan() {
+			break
+		}
+		lineNo++
+		codeLine := strings.TrimSpace(scanner.Text())
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("line %d: group code %s without a value", lineNo, codeLine)
+		}
+		lineNo++
+		value := strings.TrimSpace(scanner.Text())
+		code, err := strconv.Atoi(codeLine)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid group code %q", lineNo-1, codeLine)
+		}
+		number := func() (float64, error) {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || !finite(v) {
+				return 0, fmt.Errorf("line %d: invalid number %q", lineNo, value)
+			}
+			return v, nil
+		}
+
+		if code == 0 {
+			switch {
+			case value == "SECTION" || value == "ENDSEC" || value == "EOF":
+				if err := finishCurrent(); err != nil {
+					return nil, err
+				}
+				section = ""
+				if value == "EOF" {
+					return d, nil
+				}
+			case section != "ENTITIES":
+			case value == "VERTEX" && polyline != nil:
+				entity = &dxfEntity{kind: "VERTEX"}
+				polyline.vertices = append(polyline.vertices, dxfVertex{})
+			case value == "SEQEND" && polyline != nil:
+				if err := finishCurrent(); err != nil {
+					return nil, err
+				}
+			default:
+				if err := finishCurrent(); err != nil {
+					return nil, err
+				}
+				entity = &dxfEntity{kind: value}
+				if value == "POLYLINE" {
+					polyline = entity
+				}
+			}
+			continue
+		}
+
+		switch {
+		case code == 2 && entity == nil && section == "":
+			section = value
+		case section == "HEADER":
+			if code == 9 {
+				variable = value
+			} else if code == 70 && variable == "$INSUNITS" {
+				units, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid $INSUNITS %q", lineNo, value)
+				}
+				d.Unit = dxfUnits[units]
+			}
+		case entity == nil:
+		case entity.kind == "VERTEX":
+			// Group codes of a POLYLINE vertex.
+			v := &polyline.vertices[len(polyline.vertices)-1]
+			switch code {
+			case 10, 20, 42:
+				n, err := number()
+				if err != nil {
+					return nil, err
+				}
+				switch code {
+				case 10:
+					v.p.X = n
+				case 20:
+					v.p.Y = n
+				case 42:
+					v.bulge = n
+				}
+			}
+		default:
+			switch code {
+			case 8:
+				entity.layer = value
+			case 70:
+				flags, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid flags %q", lineNo, value)
+				}
+				entity.flags = flags
+			case 10, 20, 11, 21, 42, 230:
+				n, err := number()
+				if err != nil {
+					return nil, err
+				}
+				switch {
+				case code == 230:
+					entity.mirrored = n < 0
+				case entity.kind == "LWPOLYLINE" && code == 10:
+					entity.vertices = append(entity.vertices, dxfVertex{p: Point{X: n}})
+				case entity.kind == "LWPOLYLINE" && len(entity.vertices) > 0 && code == 20:
+					entity.vertices[len(entity.vertices)-1].p.Y = n
+				case entity.kind == "LWPOLYLINE" && len(entity.vertices) > 0 && code == 42:
+					entity.vertices[len(entity.vertices)-1].bulge = n
+				case code == 10:
+					entity.start.X = n
+				case code == 20:
+					entity.start.Y = n
+				case code == 11:
+					entity.end.X = n
+				case code == 21:
+					entity.end.Y = n
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", lineNo, err)
+	}
+	// Some exporters leave out the final EOF.
+	if err := finishCurrent(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// bulgePath flattens the corners of a polyline into points, replacing bulged
+// segments with arcs. A closed polyline's last segment runs back to its
+// first corner, which is not repeated.
+func bulgePath(vertices []dxfVertex, closed bool) []Point {
+	n := len(vertices)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	points := []Point{vertices[0].p}
+	for i := 0; i < segments; i++ {
+		a, b := vertices[i], vertices[(i+1)%n]
+		points = append(points, bulgePoints(a.p, b.p, a.bulge)...)
+	}
+	if closed {
+		points = points[:len(points)-1]
+	}
+	return points
+}
+
+// bulgePoints returns the points after p0 on the segment to p1.
+func bulgePoints(p0, p1 Point, bulge float64) []Point {
+	chord := math.Hypot(p1.X-p0.X, p1.Y-p0.Y)
+	if bulge == 0 || chord == 0 {
+		return []Point{p1}
+	}
+	angle := 4 * math.Atan(bulge)
+	// The centre lies on the chord's perpendicular, to its left for
+	// counter-clockwise arcs.
+	offset := chord / 2 / math.Tan(angle/2)
+	nx, ny := -(p1.Y-p0.Y)/chord, (p1.X-p0.X)/chord
+	cx, cy := (p0.X+p1.X)/2+nx*offset, (p0.Y+p1.Y)/2+ny*offset
+	radius := math.Hypot(p0.X-cx, p0.Y-cy)
+	start := math.Atan2(p0.Y-cy, p0.X-cx)
+	if !finite(angle, cx, cy, radius) {
+		return []Point{p1}
+	}
+
+	n := arcSegments(angle)
+	points := make([]Point, 0, n)
+	for i := 1; i < n; i++ {
+		t := start + angle*float64(i)/float64(n)
+		points = append(points, Point{cx + radius*math.Cos(t), cy + radius*math.Sin(t)})
+	}
+	return append(points, p1)
+}
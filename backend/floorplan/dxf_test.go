@@ -0,0 +1,95 @@
+package floorplan
+
+import (
+	"strings"
+	"testing"
+)
+
+// dxf builds an ASCII DXF file with the given entities, each a list of
+// group code and value pairs.
+func dxf(entities ...string) string {
+	return "0\nSECTION\n2\nENTITIES\n" + strings.Join(entities, "") + "0\nENDSEC\n0\nEOF\n"
+}
+
+// lwpolyline returns a closed LWPOLYLINE through the corners, each given as
+// x, y and bulge.
+func lwpolyline(corners ...[3]string) string {
+	s := "0\nLWPOLYLINE\n8\nWalls\n70\n1\n"
+	for _, c := range corners {
+		s += "10\n" + c[0] + "\n20\n" + c[1] + "\n42\n" + c[2] + "\n"
+	}
+	return s
+}
+
+func TestParseDXF(t *testing.T) {
+	square := [][3]string{{"0", "0", "0"}, {"4", "0", "0"}, {"4", "3", "0"}, {"0", "3", "0"}}
+	tests := []struct {
+		name    string
+		input   string
+		paths   int
+		points  int // of the first path
+		wantErr string
+	}{
+		{name: "square", input: dxf(lwpolyline(square...)), paths: 1, points: 4},
+		{name: "bulged side", input: dxf(lwpolyline([3]string{"0", "0", "1"}, [3]string{"4", "0", "0"}, [3]string{"4", "3", "0"})), paths: 1, points: 10},
+		{name: "line", input: dxf("0\nLINE\n10\n0\n20\n0\n11\n1\n21\n1\n"), paths: 1, points: 2},
+		{name: "empty", input: dxf(), paths: 0},
+		{name: "single vertex", input: dxf(lwpolyline([3]string{"1", "1", "0"})), paths: 0},
+		{name: "polygon mesh", input: dxf("0\nPOLYLINE\n70\n16\n0\nVERTEX\n10\n0\n20\n0\n0\nVERTEX\n10\n1\n20\n0\n0\nSEQEND\n"), paths: 0},
+		{name: "bulge on a zero length side", input: dxf(lwpolyline([3]string{"0", "0", "1"}, [3]string{"0", "0", "0"}, [3]string{"4", "3", "0"})), paths: 1, points: 3},
+		{name: "huge bulge", input: dxf(lwpolyline([3]string{"0", "0", "1e308"}, [3]string{"4", "0", "0"}, [3]string{"4", "3", "0"})), paths: 1},
+		{name: "huge coordinates", input: dxf(lwpolyline([3]string{"-1e308", "0", "1"}, [3]string{"1e308", "0", "0"}, [3]string{"0", "1e308", "0"})), paths: 1},
+		{name: "missing EOF", input: "0\nSECTION\n2\nENTITIES\n" + lwpolyline(square...), paths: 1, points: 4},
+		{name: "nan bulge", input: dxf(lwpolyline([3]string{"0", "0", "nan"}, [3]string{"4", "0", "0"})), wantErr: "invalid number"},
+		{name: "infinite coordinate", input: dxf(lwpolyline([3]string{"inf", "0", "0"}, [3]string{"4", "0", "0"})), wantErr: "invalid number"},
+		{name: "out of range number", input: dxf(lwpolyline([3]string{"1e999", "0", "0"}, [3]string{"4", "0", "0"})), wantErr: "invalid number"},
+		{name: "bad number", input: dxf(lwpolyline([3]string{"four", "0", "0"}, [3]string{"4", "0", "0"})), wantErr: "invalid number"},
+		{name: "bad group code", input: "0\nSECTION\nx\nENTITIES\n", wantErr: "invalid group code"},
+		{name: "group code without value", input: "0\nSECTION\n2\n", wantErr: "without a value"},
+		{name: "bad flags", input: dxf("0\nLWPOLYLINE\n70\nclosed\n"), wantErr: "invalid flags"},
+		{name: "binary", input: "AutoCAD Binary DXF\r\n\x1a\x00", wantErr: "binary DXF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDXF(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(d.Paths) != tt.paths {
+				t.Fatalf("got %d paths, want %d", len(d.Paths), tt.paths)
+			}
+			for _, p := range d.Paths {
+				for _, pt := range p.Points {
+					if !finite(pt.X, pt.Y) {
+						t.Fatalf("path has point %v", pt)
+					}
+				}
+			}
+			if tt.points > 0 && len(d.Paths[0].Points) != tt.points {
+				t.Errorf("got %d points, want %d", len(d.Paths[0].Points), tt.points)
+			}
+		})
+	}
+}
+
+func TestBulgePoints(t *testing.T) {
+	p0, p1 := Point{0, 0}, Point{2, 0}
+	for _, bulge := range []float64{0, 1, -1, 0.5, 1e308, -1e308} {
+		points := bulgePoints(p0, p1, bulge)
+		if len(points) == 0 || len(points) > 16 || points[len(points)-1] != p1 {
+			t.Errorf("bulge %g: got %v", bulge, points)
+		}
+	}
+	// A semicircle stays one radius from the middle of the chord.
+	for _, p := range bulgePoints(p0, p1, 1) {
+		if d := (p.X-1)*(p.X-1) + p.Y*p.Y; d < 0.999 || d > 1.001 {
+			t.Errorf("point %v is off the arc", p)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package floorplan generates room models from floor plans: a floor outline
+// extruded into walls, with a floor and a ceiling.
+package floorplan
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Defaults and limits for generated rooms, in metres.
+const (
+	DefaultWallHeight    = 2.5
+	DefaultWallThickness = 0.15
+	MaxCorners           = 256
+	MaxExtent            = 200
+)
+
+// minEdge is the shortest wall kept; shorter edges are merged away.
+const minEdge = 0.01
+
+// Normalize validates a floor plan and returns a cleaned copy: missing wall
+// height and thickness get defaults, repeated corners (including a closing
+// corner equal to the first) are dropped, and the outline is ordered so its
+// signed area is positive.
+func Normalize(plan models.FloorPlan) (models.FloorPlan, error) {
+	if plan.WallHeight == 0 {
+		plan.WallHeight = DefaultWallHeight
+	}
+	if plan.WallThickness == 0 {
+		plan.WallThickness = DefaultWallThickness
+	}
+	if plan.WallHeight < 1 || plan.WallHeight > 20 {
+		return plan, errors.New("wall height must be between 1 and 20 metres")
+	}
+	if plan.WallThickness < 0.01 || plan.WallThickness > 2 {
+		return plan, errors.New("wall thickness must be between 0.01 and 2 metres")
+	}
+	if len(plan.Floor) > MaxCorners {
+		return plan, fmt.Errorf("floor outline has more than %d corners", MaxCorners)
+	}
+
+	var floor []models.FloorPoint
+	for _, p := range plan.Floor {
+		if math.IsNaN(p.X) || math.IsNaN(p.Z) || math.Abs(p.X) > MaxExtent || math.Abs(p.Z) > MaxExtent {
+			return plan, fmt.Errorf("floor corners must lie within %g metres of the origin", float64(MaxExtent))
+		}
+		if len(floor) > 0 && distance(floor[len(floor)-1], p) < minEdge {
+			continue
+		}
+		floor = append(floor, p)
+	}
+	for len(floor) > 1 && distance(floor[0], floor[len(floor)-1]) < minEdge {
+		floor = floor[:len(floor)-1]
+	}
+	floor = dropCollinear(floor)
+	if len(floor) < 3 {
+		return plan, errors.New("floor outline needs at least 3 distinct corners")
+	}
+	if selfIntersects(floor) {
+		return plan, errors.New("floor outline crosses itself")
+	}
+	area := signedArea(floor)
+	if math.Abs(area) < 0.1 {
+		return plan, errors.New("floor area is too small")
+	}
+	if area < 0 {
+		for i, j := 0, len(floor)-1; i < j; i, j = i+1, j-1 {
+			floor[i], floor[j] = floor[j], floor[i]
+		}
+	}
+	plan.Floor = floor
+	return plan, nil
+}
+
+// Area returns the floor area of an outline in square metres.
+func Area(floor []models.FloorPoint) float64 {
+	return math.Abs(signedArea(floor))
+}
+
+// Bounds returns the smallest and largest corner coordinates of an outline.
+func Bounds(floor []models.FloorPoint) (min, max models.FloorPoint) {
+	if len(floor) == 0 {
+		return
+	}
+	min, max = floor[0], floor[0]
+	for _, p := range floor[1:] {
+		min.X, min.Z = math.Min(min.X, p.X), math.Min(min.Z, p.Z)
+		max.X, max.Z = math.Max(max.X, p.X), math.Max(max.Z, p.Z)
+	}
+	return min, max
+}
+
+// signedArea is positive for outlines running counter-clockwise in X/Z
+// coordinates. Seen from above in the editor, where Z points towards the
+// viewer, those run clockwise.
+func signedArea(floor []models.FloorPoint) float64 {
+	sum := 0.0
+	for i, p := range floor {
+		q := floor[(i+1)%len(floor)]
+		sum += p.X*q.Z - q.X*p.Z
+	}
+	return sum / 2
+}
+
+func distance(a, b models.FloorPoint) float64 {
+	return math.Hypot(b.X-a.X, b.Z-a.Z)
+}
+
+// cross is the z component of (b-a) x (c-a).
+func cross(a, b, c models.FloorPoint) float64 {
+	return (b.X-a.X)*(c.Z-a.Z) - (b.Z-a.Z)*(c.X-a.X)
+}
+
+// dropCollinear removes corners lying on the straight wall between their
+// neighbours, so each wall is a single segment.
+func dropCollinear(floor []models.FloorPoint) []models.FloorPoint {
+	for changed := true; changed && len(floor) > 3; {
+		changed = false
+		for i := 0; i < len(floor) && len(floor) > 3; i++ {
+			prev := floor[(i+len(floor)-1)%len(floor)]
+			next := floor[(i+1)%len(floor)]
+			p := floor[i]
+			if math.Abs(cross(prev, p, next)) < 1e-9*math.Max(1, distance(prev, next)) &&
+				(p.X-prev.X)*(next.X-p.X)+(p.Z-prev.Z)*(next.Z-p.Z) > 0 {
+				floor = append(floor[:i:i], floor[i+1:]...)
+				changed = true
+			}
+		}
+	}
+	return floor
+}
+
+// selfIntersects reports whether any two non-adjacent walls touch, or two
+// adjacent walls fold back onto each other.
+func selfIntersects(floor []models.FloorPoint) bool {
+	n := len(floor)
+	for i, p := range floor {
+		prev, next := floor[(i+n-1)%n], floor[(i+1)%n]
+		if math.Abs(cross(prev, p, next)) < 1e-12 && (prev.X-p.X)*(next.X-p.X)+(prev.Z-p.Z)*(next.Z-p.Z) > 0 {
+			return true
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue // adjacent through the closing wall
+			}
+			if segmentsIntersect(floor[i], floor[(i+1)%n], floor[j], floor[(j+1)%n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func segmentsIntersect(a, b, c, d models.FloorPoint) bool {
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	onSegment := func(p, q, r models.FloorPoint) bool {
+		return math.Min(p.X, q.X) <= r.X && r.X <= math.Max(p.X, q.X) &&
+			math.Min(p.Z, q.Z) <= r.Z && r.Z <= math.Max(p.Z, q.Z)
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
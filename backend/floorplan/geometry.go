@@ -0,0 +1,133 @@
+package floorplan
+
+import (
+	"backend/mesh"
+	"backend/models"
+)
+
+// Materials of generated rooms.
+var roomMaterials = map[string][3]float64{
+	"floor":   {0.76, 0.66, 0.52},
+	"wall":    {0.92, 0.91, 0.88},
+	"ceiling": {0.97, 0.97, 0.97},
+}
+
+// Materials returns the material library used by Build's meshes.
+func Materials() map[string]*mesh.Material {
+	materials := map[string]*mesh.Material{}
+	for name, diffuse := range roomMaterials {
+		materials[name] = &mesh.Material{Name: name, Diffuse: diffuse, Maps: map[string]string{}}
+	}
+	return materials
+}
+
+// builder accumulates flat-shaded faces.
+type builder struct {
+	m *mesh.Mesh
+}
+
+func (b *builder) vertex(p mesh.Vec3) int {
+	b.m.Positions = append(b.m.Positions, p)
+	return len(b.m.Positions) - 1
+}
+
+// polygon adds a convex planar polygon facing normal, fanned into triangles.
+// The corners may be given in either order.
+func (b *builder) polygon(corners []mesh.Vec3, normal mesh.Vec3, material string) {
+	if len(corners) < 3 {
+		return
+	}
+	e1 := sub(corners[1], corners[0])
+	e2 := sub(corners[2], corners[0])
+	if dot(crossVec(e1, e2), normal) < 0 {
+		reversed := make([]mesh.Vec3, len(corners))
+		for i, c := range corners {
+			reversed[len(corners)-1-i] = c
+		}
+		corners = reversed
+	}
+	b.m.Normals = append(b.m.Normals, normal)
+	n := len(b.m.Normals) - 1
+	first := b.vertex(corners[0])
+	prev := b.vertex(corners[1])
+	for _, c := range corners[2:] {
+		next := b.vertex(c)
+		b.m.Triangles = append(b.m.Triangles, mesh.Triangle{
+			V:        [3]mesh.Index{{P: first, T: -1, N: n}, {P: prev, T: -1, N: n}, {P: next, T: -1, N: n}},
+			Material: material,
+		})
+		prev = next
+	}
+}
+
+// Build generates the room mesh of a normalized floor plan: the floor at
+// height 0, the ceiling at the wall height, and walls of the given thickness
+// built outwards from the outline so the inside matches the plan exactly.
+// Openings, which must have passed ValidateOpenings, are cut through the
+// walls.
+func Build(plan models.FloorPlan, openings []models.RoomOpening) *mesh.Mesh {
+	b := &builder{m: &mesh.Mesh{Units: "m"}}
+	floor := plan.Floor
+	h := plan.WallHeight
+	up := mesh.Vec3{Y: 1}
+	down := mesh.Vec3{Y: -1}
+
+	// Floor and ceiling, triangulated together so they match.
+	for _, tri := range triangulate(floor) {
+		var bottom, top []mesh.Vec3
+		for _, i := range tri {
+			bottom = append(bottom, mesh.Vec3{X: floor[i].X, Z: floor[i].Z})
+			top = append(top, mesh.Vec3{X: floor[i].X, Y: h, Z: floor[i].Z})
+		}
+		b.polygon(bottom, up, "floor")
+		b.polygon(top, down, "ceiling")
+	}
+
+	byWall := map[int][]models.RoomOpening{}
+	for _, o := range openings {
+		byWall[o.Wall] = append(byWall[o.Wall], o)
+	}
+	for i, w := range Walls(plan) {
+		along := mesh.Vec3{X: w.Along.X, Z: w.Along.Z}
+		outward := mesh.Vec3{X: w.Outward.X, Z: w.Outward.Z}
+		inward := mesh.Vec3{X: -outward.X, Z: -outward.Z}
+
+		// inside and outside lift a position along the wall to a height on
+		// the wall's two faces.
+		inside := func(s, y float64) mesh.Vec3 {
+			p := w.Inside(s)
+			return mesh.Vec3{X: p.X, Y: y, Z: p.Z}
+		}
+		outside := func(s, y float64) mesh.Vec3 {
+			p := w.Outside(s)
+			return mesh.Vec3{X: p.X, Y: y, Z: p.Z}
+		}
+
+		for _, panel := range wallPanels(w.Length, h, byWall[i]) {
+			x, y := panel[0], panel[1]
+			b.polygon([]mesh.Vec3{inside(x.lo, y.lo), inside(x.hi, y.lo), inside(x.hi, y.hi), inside(x.lo, y.hi)}, inward, "wall")
+			b.polygon([]mesh.Vec3{outside(x.lo, y.lo), outside(x.hi, y.lo), outside(x.hi, y.hi), outside(x.lo, y.hi)}, outward, "wall")
+		}
+		b.polygon([]mesh.Vec3{inside(0, h), inside(w.Length, h), outside(w.Length, h), outside(0, h)}, up, "wall")
+
+		// Reveals line the hole of each opening through the wall.
+		for _, o := range byWall[i] {
+			s0, s1 := o.Offset, o.Offset+o.Width
+			y0, y1 := o.SillHeight, o.SillHeight+o.Height
+			b.polygon([]mesh.Vec3{inside(s0, y0), inside(s1, y0), outside(s1, y0), outside(s0, y0)}, up, "wall")
+			if y1 < h-1e-9 {
+				b.polygon([]mesh.Vec3{inside(s0, y1), inside(s1, y1), outside(s1, y1), outside(s0, y1)}, down, "wall")
+			}
+			b.polygon([]mesh.Vec3{inside(s0, y0), outside(s0, y0), outside(s0, y1), inside(s0, y1)}, along, "wall")
+			b.polygon([]mesh.Vec3{inside(s1, y0), outside(s1, y0), outside(s1, y1), inside(s1, y1)},
+				mesh.Vec3{X: -along.X, Z: -along.Z}, "wall")
+		}
+	}
+	return b.m
+}
+
+func sub(a, b mesh.Vec3) mesh.Vec3 { return mesh.Vec3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z} }
+func dot(a, b mesh.Vec3) float64   { return a.X*b.X + a.Y*b.Y + a.Z*b.Z }
+func crossVec(a, b mesh.Vec3) mesh.Vec3 {
+	return mesh.Vec3{X: a.Y*b.Z - a.Z*b.Y, Y: a.Z*b.X - a.X*b.Z, Z: a.X*b.Y - a.Y*b.X}
+}
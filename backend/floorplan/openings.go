@@ -0,0 +1,114 @@
+package floorplan
+
+import (
+	"backend/models"
+	"fmt"
+	"sort"
+)
+
+// Opening types.
+const (
+	OpeningDoor   = "door"
+	OpeningWindow = "window"
+	OpeningPlain  = "opening"
+)
+
+// RectangleOutline is the outline used for rooms without a floor plan: a
+// width x depth rectangle from the origin, so wall 0 runs along X, wall 1
+// along Z at x = width, and so on.
+func RectangleOutline(width, depth float64) []models.FloorPoint {
+	return []models.FloorPoint{{X: 0, Z: 0}, {X: width, Z: 0}, {X: width, Z: depth}, {X: 0, Z: depth}}
+}
+
+// WallLength returns the length of wall i of an outline.
+func WallLength(floor []models.FloorPoint, i int) float64 {
+	return distance(floor[i], floor[(i+1)%len(floor)])
+}
+
+// ValidateOpenings checks that every opening fits on its wall below the
+// ceiling and that openings on the same wall do not overlap.
+func ValidateOpenings(floor []models.FloorPoint, wallHeight float64, openings []models.RoomOpening) error {
+	byWall := map[int][]models.RoomOpening{}
+	for i, o := range openings {
+		name := fmt.Sprintf("opening %d", i+1)
+		switch o.Type {
+		case OpeningDoor:
+			if o.SillHeight != 0 {
+				return fmt.Errorf("%s: doors have no sill", name)
+			}
+			if o.Swing != "" && o.Swing != "left" && o.Swing != "right" {
+				return fmt.Errorf("%s: swing must be left, right or empty", name)
+			}
+		case OpeningWindow, OpeningPlain:
+			if o.Swing != "" {
+				return fmt.Errorf("%s: only doors swing", name)
+			}
+		default:
+			return fmt.Errorf("%s: type must be door, window or opening", name)
+		}
+		if o.Wall < 0 || o.Wall >= len(floor) {
+			return fmt.Errorf("%s: the room has walls 0 to %d", name, len(floor)-1)
+		}
+		if o.Width <= 0 || o.Height <= 0 || o.Offset < 0 || o.SillHeight < 0 {
+			return fmt.Errorf("%s: sizes must be positive", name)
+		}
+		if length := WallLength(floor, o.Wall); o.Offset+o.Width > length+1e-9 {
+			return fmt.Errorf("%s: does not fit on wall %d, which is %.2f m long", name, o.Wall, length)
+		}
+		if o.SillHeight+o.Height > wallHeight+1e-9 {
+			return fmt.Errorf("%s: reaches above the %.2f m walls", name, wallHeight)
+		}
+		byWall[o.Wall] = append(byWall[o.Wall], o)
+	}
+	for wall, list := range byWall {
+		sort.Slice(list, func(i, j int) bool { return list[i].Offset < list[j].Offset })
+		for i := 1; i < len(list); i++ {
+			if list[i].Offset < list[i-1].Offset+list[i-1].Width {
+				return fmt.Errorf("openings on wall %d overlap", wall)
+			}
+		}
+	}
+	return nil
+}
+
+// span is a closed interval along a wall or up it.
+type span struct{ lo, hi float64 }
+
+// wallPanels splits a wall of the given length and height into the solid
+// rectangles left around its openings, as (along, up) spans.
+func wallPanels(length, height float64, openings []models.RoomOpening) [][2]span {
+	cuts := []float64{0, length}
+	for _, o := range openings {
+		cuts = append(cuts, o.Offset, o.Offset+o.Width)
+	}
+	sort.Float64s(cuts)
+
+	var panels [][2]span
+	for i := 0; i+1 < len(cuts); i++ {
+		x0, x1 := cuts[i], cuts[i+1]
+		if x1-x0 < 1e-9 {
+			continue
+		}
+		// Holes covering this strip, bottom to top.
+		var holes []span
+		for _, o := range openings {
+			if o.Offset <= x0+1e-9 && o.Offset+o.Width >= x1-1e-9 {
+				holes = append(holes, span{o.SillHeight, o.SillHeight + o.Height})
+			}
+		}
+		sort.Slice(holes, func(a, b int) bool { return holes[a].lo < holes[b].lo })
+		y := 0.0
+		for _, hole := range holes {
+			if hole.lo > y+1e-9 {
+				panels = append(panels, [2]span{{x0, x1}, {y, hole.lo}})
+			}
+			if hole.hi > y {
+				y = hole.hi
+			}
+		}
+		if height > y+1e-9 {
+			panels = append(panels, [2]span{{x0, x1}, {y, height}})
+		}
+	}
+	return panels
+}
@@ -0,0 +1,548 @@
+package floorplan
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// affine is a 2D transform [a b c d e f], mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f) as in SVG's matrix().
+type affine [6]float64
+
+var identity = affine{1, 0, 0, 1, 0, 0}
+
+func (m affine) apply(p Point) Point {
+	return Point{m[0]*p.X + m[2]*p.Y + m[4], m[1]*p.X + m[3]*p.Y + m[5]}
+}
+
+// then returns the transform applying n first and m second.
+func (m affine) then(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// svgSkipped are elements whose content is not drawn where it appears.
+var svgSkipped = map[string]bool{
+	"defs": true, "symbol": true, "clipPath": true, "mask": true, "pattern": true,
+	"marker": true, "metadata": true, "text": true, "style": true,
+}
+
+// ParseSVG reads the linework of an SVG drawing: path, polygon, polyline,
+// rect and line elements, with their transforms applied. Curves are
+// flattened. A path's layer is the label or id of the top-level group it is
+// in, as Inkscape lays out layers. The unit is known when the root element
+// gives its width in absolute units along with a viewBox.
+func ParseSVG(r io.Reader) (*Drawing, error) {
+	d := &Drawing{Format: "svg"}
+	type frame struct {
+		transform affine
+		layer     string
+		skip      bool
+	}
+	var stack []frame
+	root := false
+
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVG: %w", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			attrs := map[string]string{}
+			label := ""
+			for _, a := range el.Attr {
+				if a.Name.Local == "label" && strings.Contains(a.Name.Space, "inkscape") {
+					label = a.Value
+				} else if a.Name.Space == "" {
+					attrs[a.Name.Local] = a.Value
+				}
+			}
+			if len(stack) == 0 {
+				if el.Name.Local != "svg" {
+					return nil, errors.New("invalid SVG: the root element is not svg")
+				}
+				d.Unit = svgUnit(attrs["width"], attrs["viewBox"])
+				root = true
+				stack = append(stack, frame{transform: identity})
+				continue
+			}
+
+			parent := stack[len(stack)-1]
+			transform, err := parseTransform(attrs["transform"])
+			if err != nil {
+				return nil, err
+			}
+			f := frame{
+				transform: parent.transform.then(transform),
+				layer:     parent.layer,
+				skip: parent.skip || svgSkipped[el.Name.Local] || attrs["display"] == "none" ||
+					strings.Contains(strings.ReplaceAll(attrs["style"], " ", ""), "display:none"),
+			}
+			if el.Name.Local == "g" && len(stack) == 1 {
+				f.layer = label
+				if f.layer == "" {
+					f.layer = attrs["id"]
+				}
+			}
+			stack = append(stack, f)
+			if f.skip {
+				continue
+			}
+
+			paths, err := svgElementPaths(el.Name.Local, attrs)
+			if err != nil {
+				return nil, fmt.Errorf("%s element: %w", el.Name.Local, err)
+			}
+			for _, p := range paths {
+				for i := range p.Points {
+					p.Points[i] = f.transform.apply(p.Points[i])
+				}
+				p.Layer = f.layer
+				if err := d.add(p); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if !root {
+		return nil, errors.New("invalid SVG: no svg element")
+	}
+	return d, nil
+}
+
+// svgUnit returns the user unit in metres given the root width and viewBox,
+// or 0 when they do not fix it.
+func svgUnit(width, viewBox string) float64 {
+	box := strings.FieldsFunc(viewBox, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(box) != 4 {
+		return 0
+	}
+	boxWidth, err := strconv.ParseFloat(box[2], 64)
+	if err != nil || !finite(boxWidth) || boxWidth <= 0 {
+		return 0
+	}
+	width = strings.TrimSpace(width)
+	for unit, length := range unitLengths {
+		if !strings.HasSuffix(width, unit) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSuffix(width, unit), 64)
+		if err != nil || !finite(value) || value <= 0 {
+			continue // "mm" also ends in "m"
+		}
+		return value * length / boxWidth
+	}
+	return 0
+}
+
+// svgElementPaths returns the paths drawn by a shape element, untransformed.
+func svgElementPaths(name string, attrs map[string]string) ([]Path, error) {
+	num := func(key string) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(attrs[key]), "px"), 64)
+		if err != nil || !finite(v) {
+			return 0
+		}
+		return v
+	}
+	switch name {
+	case "path":
+		return parsePathData(attrs["d"])
+	case "polygon", "polyline":
+		l := &pathLexer{s: attrs["points"]}
+		var points []Point
+		for l.more() {
+			x, err := l.number()
+			if err != nil {
+				return nil, err
+			}
+			y, err := l.number()
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, Point{x, y})
+		}
+		return []Path{{Points: points, Closed: name == "polygon"}}, nil
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil, nil
+		}
+		return []Path{{Points: []Point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}, Closed: true}}, nil
+	case "line":
+		return []Path{{Points: []Point{{num("x1"), num("y1")}, {num("x2"), num("y2")}}}}, nil
+	}
+	return nil, nil
+}
+
+// parseTransform reads an SVG transform list.
+func parseTransform(s string) (affine, error) {
+	m := identity
+	s = strings.TrimSpace(s)
+	for s != "" {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m, fmt.Errorf("invalid transform %q", s)
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], " ,"))
+		l := &pathLexer{s: s[open+1 : end]}
+		var args []float64
+		for l.more() {
+			v, err := l.number()
+			if err != nil {
+				return m, fmt.Errorf("invalid transform %q: %w", s, err)
+			}
+			args = append(args, v)
+		}
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+		var t affine
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, errors.New("matrix transform needs 6 values")
+			}
+			copy(t[:], args)
+		case "translate":
+			t = affine{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = affine{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			cos, sin := math.Cos(a), math.Sin(a)
+			t = affine{1, 0, 0, 1, cx, cy}.then(affine{cos, sin, -sin, cos, 0, 0}).then(affine{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = affine{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = affine{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("unknown transform %q", name)
+		}
+		m = m.then(t)
+		s = strings.TrimSpace(strings.TrimLeft(s[end+1:], " ,"))
+	}
+	return m, nil
+}
+
+// pathLexer reads numbers and commands from SVG path data and point lists.
+type pathLexer struct {
+	s string
+	i int
+}
+
+func (l *pathLexer) skipSpace() {
+	for l.i < len(l.s) && strings.IndexByte(" ,\t\r\n", l.s[l.i]) >= 0 {
+		l.i++
+	}
+}
+
+// more reports whether anything but separators is left.
+func (l *pathLexer) more() bool {
+	l.skipSpace()
+	return l.i < len(l.s)
+}
+
+// command returns the next command letter, if the next token is one.
+func (l *pathLexer) command() (byte, bool) {
+	if !l.more() {
+		return 0, false
+	}
+	c := l.s[l.i]
+	if (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e' && c != 'E' {
+		l.i++
+		return c, true
+	}
+	return 0, false
+}
+
+func (l *pathLexer) number() (float64, error) {
+	l.skipSpace()
+	start := l.i
+	if l.i < len(l.s) && (l.s[l.i] == '+' || l.s[l.i] == '-') {
+		l.i++
+	}
+	digits := func() {
+		for l.i < len(l.s) && l.s[l.i] >= '0' && l.s[l.i] <= '9' {
+			l.i++
+		}
+	}
+	digits()
+	if l.i < len(l.s) && l.s[l.i] == '.' {
+		l.i++
+		digits()
+	}
+	if l.i < len(l.s) && (l.s[l.i] == 'e' || l.s[l.i] == 'E') {
+		l.i++
+		if l.i < len(l.s) && (l.s[l.i] == '+' || l.s[l.i] == '-') {
+			l.i++
+		}
+		digits()
+	}
+	v, err := strconv.ParseFloat(l.s[start:l.i], 64)
+	if err != nil || !finite(v) {
+		return 0, fmt.Errorf("expected a number at %q", truncate(l.s[start:], 16))
+	}
+	return v, nil
+}
+
+// flag reads an arc flag, which may run into the next number.
+func (l *pathLexer) flag() (bool, error) {
+	l.skipSpace()
+	if l.i < len(l.s) && (l.s[l.i] == '0' || l.s[l.i] == '1') {
+		l.i++
+		return l.s[l.i-1] == '1', nil
+	}
+	return false, fmt.Errorf("expected an arc flag at %q", truncate(l.s[l.i:], 16))
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// curveSegments is the number of segments a Bézier curve is flattened into.
+const curveSegments = 8
+
+// parsePathData reads SVG path data into one path per subpath.
+func parsePathData(data string) ([]Path, error) {
+	l := &pathLexer{s: data}
+	var paths []Path
+	var current Path
+	var cur, start, ctrl Point
+	var cmd, prev byte
+
+	flush := func() {
+		if len(current.Points) > 1 {
+			paths = append(paths, current)
+		}
+		current = Path{}
+	}
+	lineTo := func(p Point) {
+		if len(current.Points) == 0 {
+			current.Points = []Point{cur}
+		}
+		current.Points = append(current.Points, p)
+		cur = p
+	}
+	point := func(rel bool) (Point, error) {
+		x, err := l.number()
+		if err != nil {
+			return Point{}, err
+		}
+		y, err := l.number()
+		if err != nil {
+			return Point{}, err
+		}
+		if rel {
+			return Point{cur.X + x, cur.Y + y}, nil
+		}
+		return Point{x, y}, nil
+	}
+
+	for l.more() {
+		if c, ok := l.command(); ok {
+			cmd = c
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("expected a path command at %q", truncate(l.s[l.i:], 16))
+		}
+		rel := cmd >= 'a'
+		upper := cmd &^ 0x20
+
+		switch upper {
+		case 'M':
+			p, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			cur, start = p, p
+			// Further pairs are implicit line-tos.
+			cmd = 'L' | cmd&0x20
+		case 'L':
+			p, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			lineTo(p)
+		case 'H', 'V':
+			v, err := l.number()
+			if err != nil {
+				return nil, err
+			}
+			p := cur
+			switch {
+			case upper == 'H' && rel:
+				p.X += v
+			case upper == 'H':
+				p.X = v
+			case rel:
+				p.Y += v
+			default:
+				p.Y = v
+			}
+			lineTo(p)
+		case 'Z':
+			if len(current.Points) > 1 {
+				current.Closed = true
+			}
+			flush()
+			cur = start
+			cmd = 0 // numbers may not follow
+		case 'C', 'S':
+			c1 := cur
+			if upper == 'S' {
+				if prev == 'C' || prev == 'S' {
+					c1 = Point{2*cur.X - ctrl.X, 2*cur.Y - ctrl.Y}
+				}
+			} else {
+				var err error
+				if c1, err = point(rel); err != nil {
+					return nil, err
+				}
+			}
+			c2, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			p, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			p0 := cur
+			for i := 1; i <= curveSegments; i++ {
+				t := float64(i) / curveSegments
+				u := 1 - t
+				lineTo(Point{
+					u*u*u*p0.X + 3*u*u*t*c1.X + 3*u*t*t*c2.X + t*t*t*p.X,
+					u*u*u*p0.Y + 3*u*u*t*c1.Y + 3*u*t*t*c2.Y + t*t*t*p.Y,
+				})
+			}
+			ctrl = c2
+		case 'Q', 'T':
+			c := cur
+			if upper == 'T' {
+				if prev == 'Q' || prev == 'T' {
+					c = Point{2*cur.X - ctrl.X, 2*cur.Y - ctrl.Y}
+				}
+			} else {
+				var err error
+				if c, err = point(rel); err != nil {
+					return nil, err
+				}
+			}
+			p, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			p0 := cur
+			for i := 1; i <= curveSegments; i++ {
+				t := float64(i) / curveSegments
+				u := 1 - t
+				lineTo(Point{u*u*p0.X + 2*u*t*c.X + t*t*p.X, u*u*p0.Y + 2*u*t*c.Y + t*t*p.Y})
+			}
+			ctrl = c
+		case 'A':
+			var radii [3]float64
+			for i := range radii {
+				v, err := l.number()
+				if err != nil {
+					return nil, err
+				}
+				radii[i] = v
+			}
+			large, err := l.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := l.flag()
+			if err != nil {
+				return nil, err
+			}
+			p, err := point(rel)
+			if err != nil {
+				return nil, err
+			}
+			for _, q := range arcPoints(cur, p, radii[0], radii[1], radii[2]*math.Pi/180, large, sweep) {
+				lineTo(q)
+			}
+		default:
+			return nil, fmt.Errorf("unknown path command %q", cmd)
+		}
+		prev = upper
+	}
+	flush()
+	return paths, nil
+}
+
+// arcPoints flattens an SVG elliptical arc from p0 to p1, following the
+// endpoint to centre conversion of the SVG specification. The points after
+// p0 are returned, ending at p1.
+func arcPoints(p0, p1 Point, rx, ry, phi float64, large, sweep bool) []Point {
+	if p0 == p1 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []Point{p1}
+	}
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (p0.X-p1.X)/2, (p0.Y-p1.Y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0.X+p1.X)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+p1.Y)/2
+
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	if !finite(cx, cy, rx, ry, delta) {
+		return []Point{p1}
+	}
+	n := arcSegments(delta)
+	points := make([]Point, 0, n)
+	for i := 1; i < n; i++ {
+		t := theta + delta*float64(i)/float64(n)
+		x, y := rx*math.Cos(t), ry*math.Sin(t)
+		points = append(points, Point{cx + cos*x - sin*y, cy + sin*x + cos*y})
+	}
+	return append(points, p1)
+}
@@ -0,0 +1,103 @@
+package floorplan
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// svg wraps elements in a root svg element 4 metres wide.
+func svg(elements string) string {
+	return `<svg xmlns="http://www.w3.org/2000/svg" width="4m" viewBox="0 0 400 300">` + elements + `</svg>`
+}
+
+func TestParseSVG(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		paths   int
+		last    *Point // last point of the first path
+		wantErr string
+	}{
+		{name: "rect", input: svg(`<rect x="0" y="0" width="400" height="300"/>`), paths: 1},
+		{name: "path", input: svg(`<path d="M0 0 H400 V300 H0 Z"/>`), paths: 1, last: &Point{0, 300}},
+		{name: "polygon", input: svg(`<polygon points="0,0 400,0 400,300"/>`), paths: 1},
+		{name: "arc", input: svg(`<path d="M0 0 A 5 5 0 0 1 10 0"/>`), paths: 1, last: &Point{10, 0}},
+		{name: "zero radius arc", input: svg(`<path d="M0 0 A 0 5 0 0 1 10 10"/>`), paths: 1, last: &Point{10, 10}},
+		{name: "huge arc", input: svg(`<path d="M0 0 A 1e308 1e308 0 0 1 10 10"/>`), paths: 1, last: &Point{10, 10}},
+		{name: "huge arc and coordinates", input: svg(`<path d="M-1e308 0 A 1e308 1e308 0 1 1 1e308 0"/>`), paths: 1},
+		{name: "arc back to its start", input: svg(`<path d="M0 0 A 5 5 0 0 1 0 0"/>`), paths: 0},
+		{name: "empty path", input: svg(`<path d=""/>`), paths: 0},
+		{name: "skipped defs", input: svg(`<defs><rect width="10" height="10"/></defs>`), paths: 0},
+		{name: "nan attribute", input: svg(`<rect x="NaN" y="0" width="400" height="300"/>`), paths: 1},
+		{name: "infinite attribute", input: svg(`<line x1="0" y1="0" x2="Inf" y2="0"/>`), paths: 1, last: &Point{0, 0}},
+		{name: "out of range number", input: svg(`<path d="M0 0 L1e999 0"/>`), wantErr: "expected a number"},
+		{name: "truncated path", input: svg(`<path d="M0"/>`), wantErr: "expected a number"},
+		{name: "unknown command", input: svg(`<path d="M0 0 X1 1"/>`), wantErr: "unknown path command"},
+		{name: "not svg", input: `<html></html>`, wantErr: "root element is not svg"},
+		{name: "empty", input: ``, wantErr: "no svg element"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseSVG(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(d.Paths) != tt.paths {
+				t.Fatalf("got %d paths, want %d", len(d.Paths), tt.paths)
+			}
+			for _, p := range d.Paths {
+				for _, pt := range p.Points {
+					if !finite(pt.X, pt.Y) {
+						t.Fatalf("path has point %v", pt)
+					}
+				}
+			}
+			if tt.last != nil {
+				points := d.Paths[0].Points
+				if last := points[len(points)-1]; math.Abs(last.X-tt.last.X) > 1e-9 || math.Abs(last.Y-tt.last.Y) > 1e-9 {
+					t.Errorf("path ends at %v, want %v", last, *tt.last)
+				}
+			}
+		})
+	}
+}
+
+func TestSVGUnit(t *testing.T) {
+	tests := []struct {
+		width, viewBox string
+		want           float64
+	}{
+		{"4m", "0 0 400 300", 0.01},
+		{"400mm", "0 0 400 300", 0.001},
+		{"400", "0 0 400 300", 0},
+		{"4m", "0 0 0 300", 0},
+		{"4m", "0 0 NaN 300", 0},
+		{"4m", "0 0 Inf 300", 0},
+		{"NaNm", "0 0 400 300", 0},
+		{"4m", "0 0 400", 0},
+	}
+	for _, tt := range tests {
+		if got := svgUnit(tt.width, tt.viewBox); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("svgUnit(%q, %q) = %g, want %g", tt.width, tt.viewBox, got, tt.want)
+		}
+	}
+}
+
+func TestArcPoints(t *testing.T) {
+	p0, p1 := Point{0, 0}, Point{10, 10}
+	for _, r := range []float64{0, 1, 5, 1e10, 1e308, math.Inf(1), math.NaN()} {
+		for _, flags := range [][2]bool{{false, false}, {false, true}, {true, false}, {true, true}} {
+			points := arcPoints(p0, p1, r, r, 0.3, flags[0], flags[1])
+			if len(points) == 0 || len(points) > 16 || points[len(points)-1] != p1 {
+				t.Errorf("radius %g, flags %v: got %v", r, flags, points)
+			}
+		}
+	}
+}
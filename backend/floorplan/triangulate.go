@@ -0,0 +1,56 @@
+package floorplan
+
+import "backend/models"
+
+// triangulate splits a simple outline with positive signed area into
+// triangles by ear clipping. Triangles are returned as corner indices with
+// the outline's winding.
+func triangulate(floor []models.FloorPoint) [][3]int {
+	remaining := make([]int, len(floor))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	var triangles [][3]int
+	for len(remaining) > 3 {
+		clipped := false
+		for i := range remaining {
+			n := len(remaining)
+			a, b, c := remaining[(i+n-1)%n], remaining[i], remaining[(i+1)%n]
+			if !isEar(floor, remaining, a, b, c) {
+				continue
+			}
+			triangles = append(triangles, [3]int{a, b, c})
+			remaining = append(remaining[:i:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			// Only reachable through rounding on nearly degenerate outlines;
+			// fan out the rest rather than fail.
+			for i := 1; i+1 < len(remaining); i++ {
+				triangles = append(triangles, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return triangles
+		}
+	}
+	return append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+// isEar reports whether corner b, between a and c, is convex and its triangle
+// contains no other remaining corner.
+func isEar(floor []models.FloorPoint, remaining []int, a, b, c int) bool {
+	pa, pb, pc := floor[a], floor[b], floor[c]
+	if cross(pa, pb, pc) <= 0 {
+		return false
+	}
+	for _, i := range remaining {
+		if i == a || i == b || i == c {
+			continue
+		}
+		p := floor[i]
+		if cross(pa, pb, p) >= 0 && cross(pb, pc, p) >= 0 && cross(pc, pa, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package floorplan
+
+import (
+	"backend/models"
+	"math"
+	"sort"
+)
+
+// Wall is a straight wall of a floor plan seen from above. Positions along
+// it are measured in metres from Start on its inside face.
+type Wall struct {
+	Start, End models.FloorPoint // ends of the inside face
+	Length     float64
+	// Along is the unit direction from Start to End and Outward the unit
+	// normal pointing away from the room.
+	Along, Outward models.FloorPoint
+	Thickness      float64
+
+	outerStart, outerEnd models.FloorPoint // mitred corners of the outside face
+}
+
+// Walls returns the walls of a normalized floor plan in outline order, so
+// wall i runs from corner i to corner i+1.
+func Walls(plan models.FloorPlan) []Wall {
+	floor := plan.Floor
+	outer := offsetOutline(floor, plan.WallThickness)
+	walls := make([]Wall, len(floor))
+	for i, a := range floor {
+		j := (i + 1) % len(floor)
+		c := floor[j]
+		length := distance(a, c)
+		outward := outwardNormal(a, c)
+		walls[i] = Wall{
+			Start:      a,
+			End:        c,
+			Length:     length,
+			Along:      models.FloorPoint{X: (c.X - a.X) / length, Z: (c.Z - a.Z) / length},
+			Outward:    models.FloorPoint{X: outward.X, Z: outward.Z},
+			Thickness:  plan.WallThickness,
+			outerStart: outer[i],
+			outerEnd:   outer[j],
+		}
+	}
+	return walls
+}
+
+// Inside returns the point s metres along the inside face.
+func (w Wall) Inside(s float64) models.FloorPoint {
+	return models.FloorPoint{X: w.Start.X + w.Along.X*s, Z: w.Start.Z + w.Along.Z*s}
+}
+
+// Outside returns the point of the outside face across from Inside(s). At
+// the ends of the wall these are the mitred corners shared with the
+// neighbouring walls.
+func (w Wall) Outside(s float64) models.FloorPoint {
+	switch {
+	case s <= 1e-9:
+		return w.outerStart
+	case s >= w.Length-1e-9:
+		return w.outerEnd
+	}
+	p := w.Inside(s)
+	return models.FloorPoint{X: p.X + w.Outward.X*w.Thickness, Z: p.Z + w.Outward.Z*w.Thickness}
+}
+
+// Pieces returns the solid parts of the wall left between its openings, as
+// quadrilaterals: inside start, inside end, outside end, outside start.
+func (w Wall) Pieces(openings []models.RoomOpening) [][4]models.FloorPoint {
+	sorted := append([]models.RoomOpening(nil), openings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	var pieces [][4]models.FloorPoint
+	piece := func(s0, s1 float64) {
+		if s1-s0 > 1e-9 {
+			pieces = append(pieces, [4]models.FloorPoint{w.Inside(s0), w.Inside(s1), w.Outside(s1), w.Outside(s0)})
+		}
+	}
+	s := 0.0
+	for _, o := range sorted {
+		piece(s, o.Offset)
+		s = math.Max(s, o.Offset+o.Width)
+	}
+	piece(s, w.Length)
+	return pieces
+}
+
+// outwardNormal is the horizontal unit normal of the wall from a to c that
+// points away from the room.
+func outwardNormal(a, c models.FloorPoint) models.FloorPoint {
+	dx, dz := c.X-a.X, c.Z-a.Z
+	l := math.Hypot(dx, dz)
+	return models.FloorPoint{X: dz / l, Z: -dx / l}
+}
+
+// maxMiter caps how far a wall corner reaches out at sharp angles, in wall
+// thicknesses.
+const maxMiter = 4
+
+// offsetOutline moves every corner outwards by thickness, mitring the corners.
+func offsetOutline(floor []models.FloorPoint, thickness float64) []models.FloorPoint {
+	n := len(floor)
+	outer := make([]models.FloorPoint, n)
+	for i, p := range floor {
+		n1 := outwardNormal(floor[(i+n-1)%n], p)
+		n2 := outwardNormal(p, floor[(i+1)%n])
+		scale := thickness / (1 + n1.X*n2.X + n1.Z*n2.Z)
+		dx, dz := (n1.X+n2.X)*scale, (n1.Z+n2.Z)*scale
+		if l := math.Hypot(dx, dz); l > maxMiter*thickness {
+			dx, dz = dx/l*maxMiter*thickness, dz/l*maxMiter*thickness
+		}
+		outer[i] = models.FloorPoint{X: p.X + dx, Z: p.Z + dz}
+	}
+	return outer
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/storage"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// modelContentTypes covers 3D formats the system MIME table often lacks or
+// gets wrong (.obj is commonly mapped to application/x-tgif).
+var modelContentTypes = map[string]string{
+	".obj":  "model/obj",
+	".mtl":  "model/mtl",
+	".glb":  "model/gltf-binary",
+	".gltf": "model/gltf+json",
+}
+
+// ServeAssetFile streams a file from local asset storage. URLs issued by
+// assetURL carry an expiry and an HMAC signature; requests without a valid,
+// unexpired signature are refused so catalog models cannot be scraped.
+func ServeAssetFile(c *gin.Context) {
+	local, ok := storage.Assets.(*storage.Local)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assets are not served by this server"})
+		return
+	}
+	key := storage.NormalizeKey(c.Param("key"))
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		return
+	}
+
+	if local.Signer != nil {
+		err := local.Signer.Verify(key, c.Query("expires"), c.Query("sig"), time.Now())
+		if errors.Is(err, storage.ErrURLExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": "Asset URL has expired"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid asset URL signature"})
+			return
+		}
+	}
+
+	rc, err := local.Open(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening asset %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not read asset"})
+		return
+	}
+	defer rc.Close()
+
+	contentType := modelContentTypes[strings.ToLower(path.Ext(key))]
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+
+	// Content-hashed files never change, so they are cached for good under
+	// their hash. Other files may change in place and are cached privately
+	// until the signed URL expires.
+	hash, hashed := blobs.HashFromKey(key)
+	if hashed {
+		c.Header("ETag", `"`+hash+`"`)
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		cacheControl := "no-cache"
+		if expires, err := strconv.ParseInt(c.Query("expires"), 10, 64); err == nil && local.Signer != nil {
+			if maxAge := expires - time.Now().Unix(); maxAge > 0 {
+				cacheControl = fmt.Sprintf("private, max-age=%d", maxAge)
+			}
+		}
+		c.Header("Cache-Control", cacheControl)
+	}
+
+	// Files support range requests and If-None-Match/If-Modified-Since.
+	if f, ok := rc.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			if !hashed {
+				c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+			}
+			http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), f)
+			return
+		}
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		log.Printf("Error streaming asset %s: %v", key, err)
+	}
+}
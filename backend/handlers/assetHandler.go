@@ -1,21 +1,57 @@
 package handlers
 
 import (
+	"backend/blobs"
 	"backend/db"
 	"backend/models"
+	"backend/storage"
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
-	"strings"
+	"path"
 )
 
-func transformAssetPath(dbPath string) string {
-	// Convert backslashes to forward slashes
-	cleanPath := strings.ReplaceAll(dbPath, "\\", "/")
-	// Remove the redundant prefix "assets/objects/" if it exists
-	return strings.TrimPrefix(cleanPath, "assets/objects/")
+// assetURL resolves a stored asset path or key to the URL clients load it from.
+func assetURL(dbPath string) string {
+	return storage.URLFor(dbPath)
+}
+
+// openAsset opens a stored asset by its database path or key.
+func openAsset(ctx context.Context, dbPath string) (io.ReadCloser, error) {
+	return storage.Assets.Open(ctx, storage.NormalizeKey(dbPath))
+}
+
+// assetBlobs returns the content-addressed store on the configured backend.
+func assetBlobs() *blobs.Store {
+	return &blobs.Store{DB: db.DB, Files: storage.Assets}
+}
+
+// storeUploadedBlobs stores uploaded files by content hash. It returns the
+// blobs by base file name and the references to attach to their owner row.
+func storeUploadedBlobs(ctx context.Context, files []*multipart.FileHeader) (map[string]blobs.Blob, []blobs.Ref, error) {
+	store := assetBlobs()
+	stored := map[string]blobs.Blob{}
+	var refs []blobs.Ref
+	for _, fh := range files {
+		name := path.Base(fh.Filename)
+		f, err := fh.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		blob, err := store.Put(ctx, f, name, fh.Header.Get("Content-Type"))
+		f.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		stored[name] = blob
+		refs = append(refs, blobs.Ref{Name: name, Hash: blob.Hash})
+	}
+	return stored, refs, nil
 }
 
 func GetAssetByID(c *gin.Context) {
@@ -26,8 +62,8 @@ func GetAssetByID(c *gin.Context) {
 	}
 
 	var asset models.A
-	err := db.DB.QueryRow("SELECT id, name, obj_file_path, thumbnail_path, texture_path FROM room WHERE id = $1", id).
-		Scan(&asset.ID, &asset.Name, &asset.Object, &asset.Thumbnail, &asset.Texture)
+	err := db.DB.QueryRow("SELECT id, name, obj_file_path, thumbnail_path, texture_path, glb_file_path FROM room WHERE id = $1", id).
+		Scan(&asset.ID, &asset.Name, &asset.Object, &asset.Thumbnail, &asset.Texture, &asset.Glb)
 	if err != nil {
 		log.Printf("Database query error: %v", err)
 		if errors.Is(err, sql.ErrNoRows) {
@@ -38,15 +74,13 @@ func GetAssetByID(c *gin.Context) {
 		return
 	}
 
-	// Transform the path from the database
-	asset.Object = transformAssetPath(asset.Object)
-	asset.Thumbnail = transformAssetPath(asset.Thumbnail)
-	asset.Texture = transformAssetPath(asset.Texture)
-
-	// Construct the full URL for the OBJ file
-	fullObjURL := "http://localhost:8080/assets/" + asset.Object
-	log.Printf("Serving OBJ file at: %s", fullObjURL)
+	// Resolve the stored keys to download URLs
+	asset.Object = assetURL(asset.Object)
+	asset.Thumbnail = assetURL(asset.Thumbnail)
+	asset.Texture = assetURL(asset.Texture)
+	asset.Glb = assetURL(asset.Glb)
+	asset.ModelURL, asset.Format = chooseModel(preferredModelFormat(c), asset.Object, asset.Glb)
+	log.Printf("Serving model file at: %s", asset.ModelURL)
 
-	// Return the asset as JSON (you can include the URL or the transformed path)
 	c.JSON(http.StatusOK, asset)
 }
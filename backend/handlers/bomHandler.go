@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildBillOfMaterials groups a project's placements by catalog item and
+// variant. Line and currency totals are summed in SQL to avoid float drift.
+func buildBillOfMaterials(project models.Project) (models.BillOfMaterials, error) {
+	bom := models.BillOfMaterials{
+		ProjectID:        project.ID,
+		ProjectName:      project.Name,
+		Lines:            []models.BOMLine{},
+		TotalsByCurrency: map[string]float64{},
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT f.id, pf.variant_id, COALESCE(f.name, ''), COALESCE(v.name, ''), COALESCE(f.sku, ''), f.supplier,
+		       count(*), f.price, f.currency, count(*) * f.price
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		LEFT JOIN furniture_variant v ON pf.variant_id = v.id
+		WHERE pf.project_id = $1
+		GROUP BY f.id, pf.variant_id, v.name
+		ORDER BY f.name, v.name NULLS FIRST
+	`, project.ID)
+	if err != nil {
+		return bom, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.BOMLine
+		var variantID *int
+		err := rows.Scan(&line.FurnitureID, &variantID, &line.Name, &line.VariantName, &line.SKU, &line.Supplier,
+			&line.Quantity, &line.UnitPrice, &line.Currency, &line.LineTotal)
+		if err != nil {
+			return bom, err
+		}
+		line.VariantID = variantID
+		bom.Lines = append(bom.Lines, line)
+		bom.ItemCount += line.Quantity
+	}
+	if err := rows.Err(); err != nil {
+		return bom, err
+	}
+
+	totals, err := db.DB.Query(`
+		SELECT f.currency, sum(f.price)
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		WHERE pf.project_id = $1
+		GROUP BY f.currency
+	`, project.ID)
+	if err != nil {
+		return bom, err
+	}
+	defer totals.Close()
+	for totals.Next() {
+		var currency string
+		var total float64
+		if err := totals.Scan(&currency, &total); err != nil {
+			return bom, err
+		}
+		bom.TotalsByCurrency[currency] = total
+	}
+	if err := totals.Err(); err != nil {
+		return bom, err
+	}
+
+	if len(bom.TotalsByCurrency) == 1 {
+		for currency, total := range bom.TotalsByCurrency {
+			grandTotal := total
+			bom.GrandTotal = &grandTotal
+			bom.Currency = currency
+		}
+	}
+	return bom, nil
+}
+
+// writeBOMCSV streams a bill of materials as a CSV attachment.
+func writeBOMCSV(c *gin.Context, bom models.BillOfMaterials) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d-bom.csv"`, bom.ProjectID))
+	c.Status(http.StatusOK)
+
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"sku", "name", "variant", "supplier", "quantity", "unit_price", "currency", "line_total"})
+	quantities := map[string]int{}
+	for _, line := range bom.Lines {
+		w.Write([]string{
+			line.SKU, line.Name, line.VariantName, line.Supplier,
+			strconv.Itoa(line.Quantity), money(line.UnitPrice), line.Currency, money(line.LineTotal),
+		})
+		quantities[line.Currency] += line.Quantity
+	}
+	// One total per currency, in a stable order, counting the items priced
+	// in it.
+	currencies := make([]string, 0, len(bom.TotalsByCurrency))
+	for currency := range bom.TotalsByCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		w.Write([]string{
+			"", "Total", "", "", strconv.Itoa(quantities[currency]), "", currency, money(bom.TotalsByCurrency[currency]),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Error writing BOM CSV: %v", err)
+	}
+}
+
+// GetProjectBOM returns the bill of materials of a project as JSON, or as CSV
+// when format=csv is given or the client accepts text/csv.
+func GetProjectBOM(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	project, ok := loadUserProject(c, projectID)
+	if !ok {
+		return
+	}
+
+	bom, err := buildBillOfMaterials(project)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build bill of materials: " + err.Error()})
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "csv" || (format == "" && strings.Contains(c.GetHeader("Accept"), "text/csv")) {
+		writeBOMCSV(c, bom)
+		return
+	}
+	if format != "" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format: " + format})
+		return
+	}
+	c.JSON(http.StatusOK, bom)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// buildBudgetReport totals a project's placed furniture by category. Items
+// priced in another currency than the budget are reported as warnings since
+// the catalog holds no exchange rates.
+func buildBudgetReport(project models.Project) (models.BudgetReport, error) {
+	report := models.BudgetReport{
+		ProjectID:  project.ID,
+		Budget:     project.Budget,
+		Currency:   project.BudgetCurrency,
+		ByCategory: []models.CategorySpend{},
+		Warnings:   []string{},
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT COALESCE(f.category, ''), f.currency, count(*), sum(f.price)
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		WHERE pf.project_id = $1
+		GROUP BY 1, 2
+		ORDER BY 4 DESC
+	`, project.ID)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var spend models.CategorySpend
+		var currency string
+		if err := rows.Scan(&spend.Category, &currency, &spend.Items, &spend.Spent); err != nil {
+			return report, err
+		}
+		if currency != report.Currency {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"%d item(s) priced in %s are not included in the %s total", spend.Items, currency, report.Currency))
+			continue
+		}
+		report.Spent += spend.Spent
+		report.ByCategory = append(report.ByCategory, spend)
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	if report.Budget != nil {
+		remaining := *report.Budget - report.Spent
+		report.Remaining = &remaining
+		if remaining < 0 {
+			report.OverBudget = true
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"Project is %.2f %s over its budget of %.2f %s", -remaining, report.Currency, *report.Budget, report.Currency))
+		}
+	}
+	return report, nil
+}
+
+// budgetImpact works out how adding one unit of furnitureID changes the
+// project's spend, with a warning when it pushes the project over budget.
+func budgetImpact(projectID, furnitureID int) (*models.BudgetImpact, []string, error) {
+	project, err := scanProject(db.DB.QueryRow(`SELECT `+projectColumns+` FROM projects p WHERE p.id = $1`, projectID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	impact := &models.BudgetImpact{Budget: project.Budget}
+	var itemName string
+	err = db.DB.QueryRow(`SELECT COALESCE(name, ''), price, currency FROM furniture WHERE id = $1`, furnitureID).
+		Scan(&itemName, &impact.ItemPrice, &impact.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := buildBudgetReport(project)
+	if err != nil {
+		return nil, nil, err
+	}
+	impact.SpentBefore = report.Spent
+	impact.SpentAfter = report.Spent
+
+	var warnings []string
+	if impact.Currency != project.BudgetCurrency {
+		warnings = append(warnings, fmt.Sprintf("%s is priced in %s; the project budget is in %s",
+			itemName, impact.Currency, project.BudgetCurrency))
+		return impact, warnings, nil
+	}
+	impact.SpentAfter += impact.ItemPrice
+	if project.Budget != nil {
+		remaining := *project.Budget - impact.SpentAfter
+		impact.Remaining = &remaining
+		if remaining < 0 {
+			warnings = append(warnings, fmt.Sprintf("Adding %s (%.2f %s) exceeds the project budget of %.2f %s by %.2f %s",
+				itemName, impact.ItemPrice, impact.Currency, *project.Budget, project.BudgetCurrency, -remaining, project.BudgetCurrency))
+		}
+	}
+	return impact, warnings, nil
+}
+
+// GetProjectBudget reports spend against budget for a project, by category.
+func GetProjectBudget(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	project, ok := loadUserProject(c, projectID)
+	if !ok {
+		return
+	}
+
+	report, err := buildBudgetReport(project)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build budget report: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdateProjectBudget sets or clears (budget: null) a project's budget.
+func UpdateProjectBudget(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	project, ok := loadUserProject(c, projectID)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Budget   *float64 `json:"budget"`
+		Currency string   `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+	if body.Budget != nil && *body.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget cannot be negative"})
+		return
+	}
+	if body.Currency == "" {
+		body.Currency = project.BudgetCurrency
+	}
+	currency, err := normalizeCurrency(body.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = db.DB.Exec(`UPDATE projects SET budget = $1, budget_currency = $2 WHERE id = $3`, body.Budget, currency, projectID)
+	if err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget: " + err.Error()})
+		return
+	}
+	project.Budget = body.Budget
+	project.BudgetCurrency = currency
+
+	report, err := buildBudgetReport(project)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build budget report: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
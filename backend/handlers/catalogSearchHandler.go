@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 24
+	maxPageSize     = 100
+	maxTagFacets    = 50
+)
+
+// catalogSortColumns maps the sort query parameter onto ORDER BY expressions.
+var catalogSortColumns = map[string]string{
+	"name":   "name",
+	"width":  "width",
+	"depth":  "depth",
+	"height": "height",
+	"newest": "id",
+}
+
+// normalizeTags lower-cases, trims and de-duplicates tags.
+func normalizeTags(raw []string) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, entry := range raw {
+		for _, tag := range strings.Split(entry, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// catalogFilter builds the search conditions from the query string. The facet
+// named by skip ("category", "tag" or "dimensions") is left out so its counts
+// reflect every other active filter. The returned placeholder refers to the
+// text query and is empty when q is not set.
+func catalogFilter(c *gin.Context, skip string) (*sqlFilter, string, error) {
+	filter := &sqlFilter{}
+	queryParam := ""
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		queryParam = filter.bind(q)
+		filter.conditions = append(filter.conditions, fmt.Sprintf(
+			"(search_vector @@ websearch_to_tsquery('simple', %[1]s::text) OR name ILIKE '%%' || %[1]s::text || '%%')", queryParam))
+	}
+	if skip != "category" {
+		if categories := normalizeTags(c.QueryArray("category")); len(categories) > 0 {
+			filter.add("category = ANY(?)", pq.Array(categories))
+		}
+	}
+	if skip != "tag" {
+		if tags := normalizeTags(c.QueryArray("tag")); len(tags) > 0 {
+			filter.add("tags @> ?", pq.Array(tags))
+		}
+	}
+	if skip != "dimensions" {
+		if err := filter.addRanges(c, furnitureDimensionColumns); err != nil {
+			return nil, "", err
+		}
+	}
+	return filter, queryParam, nil
+}
+
+// catalogOrder turns the sort parameter ("name", "-width", "relevance", ...)
+// into an ORDER BY clause. Relevance needs the text query placeholder.
+func catalogOrder(sort, queryParam string) (string, error) {
+	if sort == "" {
+		if queryParam != "" {
+			sort = "relevance"
+		} else {
+			sort = "name"
+		}
+	}
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+	if sort == "relevance" {
+		if queryParam == "" {
+			return "", fmt.Errorf("sort=relevance requires q")
+		}
+		// Best match first regardless of the prefix.
+		return fmt.Sprintf(" ORDER BY ts_rank(search_vector, websearch_to_tsquery('simple', %s)) DESC, id", queryParam), nil
+	}
+	column, ok := catalogSortColumns[sort]
+	if !ok {
+		return "", fmt.Errorf("unsupported sort %q", sort)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id", column, direction), nil
+}
+
+// SearchFurniture searches the catalog by name and tags (q), category, tag
+// and min_/max_ width, depth and height. It returns one page of results with
+// facet counts for categories, tags and the dimension ranges.
+func SearchFurniture(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("page_size must be between 1 and %d", maxPageSize)})
+		return
+	}
+
+	filter, queryParam, err := catalogFilter(c, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	order, err := catalogOrder(c.Query("sort"), queryParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int
+	if err := db.DB.QueryRow("SELECT count(*) FROM furniture"+filter.where(), filter.args...).Scan(&total); err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+
+	limit := filter.bind(pageSize)
+	offset := filter.bind((page - 1) * pageSize)
+	rows, err := db.DB.Query(
+		"SELECT "+furnitureColumns+" FROM furniture"+filter.where()+order+" LIMIT "+limit+" OFFSET "+offset,
+		filter.args...)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	items := []models.Furniture{}
+	for rows.Next() {
+		furniture, err := scanFurniture(rows)
+		if err != nil {
+			log.Printf("Row scan error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+			return
+		}
+		items = append(items, furniture)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Rows iteration error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+
+	if err := attachVariants(items); err != nil {
+		log.Printf("Variant query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+	if err := attachLODs(items); err != nil {
+		log.Printf("LOD query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+	applyModelFormat(c, items)
+
+	facets, err := catalogFacets(c)
+	if err != nil {
+		log.Printf("Facet query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     items,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"facets":    facets,
+	})
+}
+
+// facetCount is a single value of a category or tag facet.
+type facetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// catalogFacets computes category and tag counts plus dimension ranges. Each
+// facet ignores its own filter so the client can offer alternatives.
+func catalogFacets(c *gin.Context) (gin.H, error) {
+	countValues := func(query string, args []interface{}) ([]facetCount, error) {
+		rows, err := db.DB.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		counts := []facetCount{}
+		for rows.Next() {
+			var fc facetCount
+			if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+				return nil, err
+			}
+			counts = append(counts, fc)
+		}
+		return counts, rows.Err()
+	}
+
+	filter, _, err := catalogFilter(c, "category")
+	if err != nil {
+		return nil, err
+	}
+	categories, err := countValues(
+		"SELECT COALESCE(category, ''), count(*) FROM furniture"+filter.where()+" GROUP BY 1 ORDER BY 2 DESC, 1",
+		filter.args)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter, _, err = catalogFilter(c, "tag"); err != nil {
+		return nil, err
+	}
+	tags, err := countValues(
+		"SELECT tag, count(*) FROM furniture CROSS JOIN LATERAL unnest(tags) AS tag"+filter.where()+
+			fmt.Sprintf(" GROUP BY tag ORDER BY 2 DESC, 1 LIMIT %d", maxTagFacets),
+		filter.args)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter, _, err = catalogFilter(c, "dimensions"); err != nil {
+		return nil, err
+	}
+	var minW, maxW, minD, maxD, minH, maxH sql.NullFloat64
+	err = db.DB.QueryRow(
+		"SELECT min(width), max(width), min(depth), max(depth), min(height), max(height) FROM furniture"+filter.where(),
+		filter.args...).Scan(&minW, &maxW, &minD, &maxD, &minH, &maxH)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"categories": categories,
+		"tags":       tags,
+		"dimensions": gin.H{
+			"width":  gin.H{"min": minW.Float64, "max": maxW.Float64},
+			"depth":  gin.H{"min": minD.Float64, "max": maxD.Float64},
+			"height": gin.H{"min": minH.Float64, "max": maxH.Float64},
+		},
+	}, nil
+}
+
+// GetFurnitureCategories lists the catalog categories with item counts.
+func GetFurnitureCategories(c *gin.Context) {
+	rows, err := db.DB.Query(`
+		SELECT fc.slug, fc.name, count(f.id)
+		FROM furniture_category fc
+		LEFT JOIN furniture f ON f.category = fc.slug
+		GROUP BY fc.slug, fc.name
+		ORDER BY fc.name
+	`)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	categories := []models.FurnitureCategory{}
+	for rows.Next() {
+		var category models.FurnitureCategory
+		if err := rows.Scan(&category.Slug, &category.Name, &category.Count); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		categories = append(categories, category)
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// categoryExists reports whether slug names a catalog category.
+func categoryExists(slug string) (bool, error) {
+	var exists bool
+	err := db.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM furniture_category WHERE slug = $1)", slug).Scan(&exists)
+	return exists, err
+}
+
+// UpdateFurnitureClassification sets the category and tags of a catalog item.
+// An empty category clears it.
+func UpdateFurnitureClassification(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+
+	var body struct {
+		Category string   `json:"category"`
+		Tags     []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	category := sql.NullString{String: strings.ToLower(strings.TrimSpace(body.Category))}
+	if category.String != "" {
+		exists, err := categoryExists(category.String)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown category: " + category.String})
+			return
+		}
+		category.Valid = true
+	}
+
+	row := db.DB.QueryRow("UPDATE furniture SET category = $1, tags = $2 WHERE id = $3 RETURNING "+furnitureColumns,
+		category, pq.Array(normalizeTags(body.Tags)), id)
+	furniture, err := scanFurniture(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		} else {
+			log.Printf("Database update error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update furniture: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, furniture)
+}
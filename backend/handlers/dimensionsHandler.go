@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/mesh"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// measureModel parses the OBJ file stored at dbPath and returns its metadata.
+func measureModel(ctx context.Context, dbPath string) (mesh.Metadata, error) {
+	f, err := openAsset(ctx, dbPath)
+	if err != nil {
+		return mesh.Metadata{}, err
+	}
+	defer f.Close()
+
+	model, err := mesh.ParseOBJ(f)
+	if err != nil {
+		return mesh.Metadata{}, err
+	}
+	return mesh.Inspect(model, nil), nil
+}
+
+// metresFromBounds converts model bounds to width, depth and height in metres.
+func metresFromBounds(meta mesh.Metadata) (width, depth, height float64) {
+	scale := mesh.UnitScale(meta.Units)
+	size := meta.Bounds.Size()
+	return size.X * scale, size.Z * scale, size.Y * scale
+}
+
+// roomOrigin returns the lowest X and Z of a room model's bounds in metres:
+// where its floor starts in the editor, which loads the model as it is.
+func roomOrigin(meta mesh.Metadata) (x, z float64) {
+	scale := mesh.UnitScale(meta.Units)
+	return meta.Bounds.Min.X * scale, meta.Bounds.Min.Z * scale
+}
+
+// modelOrigin returns the lowest corner of a furniture model's bounds in
+// metres, which sits that far from the item's position in the editor.
+func modelOrigin(meta mesh.Metadata) mesh.Vec3 {
+	scale := mesh.UnitScale(meta.Units)
+	low := meta.Bounds.Min
+	return mesh.Vec3{X: low.X * scale, Y: low.Y * scale, Z: low.Z * scale}
+}
+
+// UpdateFurnitureDimensions sets manual width/depth/height overrides for a
+// catalog item. Omitting every field resets the item to its model bounds.
+func UpdateFurnitureDimensions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+
+	var body struct {
+		Width  *float64 `json:"width"`
+		Depth  *float64 `json:"depth"`
+		Height *float64 `json:"height"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	var objPath, units string
+	var boundsWidth, boundsDepth, boundsHeight float64
+	var hasOrigin bool
+	err = db.DB.QueryRow(`
+		SELECT obj_file_path, units, bounds_width, bounds_depth, bounds_height, origin_x IS NOT NULL
+		FROM furniture WHERE id = $1`, id).
+		Scan(&objPath, &units, &boundsWidth, &boundsDepth, &boundsHeight, &hasOrigin)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+
+	scale := mesh.UnitScale(units)
+	width, depth, height := boundsWidth*scale, boundsDepth*scale, boundsHeight*scale
+	var originX, originY, originZ sql.NullFloat64 // kept unless the model is measured
+	unmeasured := boundsWidth == 0 && boundsDepth == 0 && boundsHeight == 0
+	if unmeasured || !hasOrigin {
+		// Rows created before metadata extraction have no recorded bounds,
+		// and older rows no recorded origin.
+		if meta, err := measureModel(c.Request.Context(), objPath); err == nil {
+			if unmeasured {
+				width, depth, height = metresFromBounds(meta)
+			}
+			origin := modelOrigin(meta)
+			originX = sql.NullFloat64{Float64: origin.X, Valid: true}
+			originY = sql.NullFloat64{Float64: origin.Y, Valid: true}
+			originZ = sql.NullFloat64{Float64: origin.Z, Valid: true}
+		} else {
+			log.Printf("Could not measure model %s: %v", objPath, err)
+		}
+	}
+
+	source := "model"
+	for _, override := range []struct {
+		value  *float64
+		target *float64
+	}{{body.Width, &width}, {body.Depth, &depth}, {body.Height, &height}} {
+		if override.value == nil {
+			continue
+		}
+		if *override.value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dimensions must be positive"})
+			return
+		}
+		*override.target = *override.value
+		source = "manual"
+	}
+
+	_, err = db.DB.Exec(`
+		UPDATE furniture SET width = $1, depth = $2, height = $3, dimensions_source = $4,
+		                     origin_x = COALESCE($5, origin_x), origin_y = COALESCE($6, origin_y),
+		                     origin_z = COALESCE($7, origin_z)
+		WHERE id = $8`,
+		width, depth, height, source, originX, originY, originZ, id)
+	if err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dimensions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "width": width, "depth": depth, "height": height, "dimensions_source": source})
+}
+
+// UpdateRoomDimensions sets manual overrides for a room's width, depth,
+// ceiling height and floor area. Fields that are omitted are measured from the
+// room model; the floor area defaults to width x depth.
+func UpdateRoomDimensions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return
+	}
+
+	var body struct {
+		Width         *float64 `json:"width"`
+		Depth         *float64 `json:"depth"`
+		CeilingHeight *float64 `json:"ceiling_height"`
+		FloorArea     *float64 `json:"floor_area"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	var objPath string
+	var ownerID sql.NullInt64
+	if err := db.DB.QueryRow(`SELECT obj_file_path, owner_id FROM room WHERE id = $1`, id).Scan(&objPath, &ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+	if !canEditRoom(c, ownerID) {
+		return
+	}
+
+	var width, depth, ceilingHeight float64
+	var originX, originZ sql.NullFloat64 // kept unless the model is measured
+	if body.Width == nil || body.Depth == nil || body.CeilingHeight == nil {
+		meta, err := measureModel(c.Request.Context(), objPath)
+		if err != nil {
+			log.Printf("Could not measure room model %s: %v", objPath, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Could not measure room model: %v", err)})
+			return
+		}
+		width, depth, ceilingHeight = metresFromBounds(meta)
+		originX.Float64, originZ.Float64 = roomOrigin(meta)
+		originX.Valid, originZ.Valid = true, true
+	}
+
+	source := "model"
+	for _, override := range []struct {
+		value  *float64
+		target *float64
+	}{{body.Width, &width}, {body.Depth, &depth}, {body.CeilingHeight, &ceilingHeight}} {
+		if override.value == nil {
+			continue
+		}
+		if *override.value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dimensions must be positive"})
+			return
+		}
+		*override.target = *override.value
+		source = "manual"
+	}
+	floorArea := width * depth
+	if body.FloorArea != nil {
+		if *body.FloorArea <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dimensions must be positive"})
+			return
+		}
+		floorArea = *body.FloorArea
+		source = "manual"
+	}
+
+	_, err = db.DB.Exec(`
+		UPDATE room SET width = $1, depth = $2, ceiling_height = $3, floor_area = $4, dimensions_source = $5,
+		                origin_x = COALESCE($6, origin_x), origin_z = COALESCE($7, origin_z)
+		WHERE id = $8`,
+		width, depth, ceilingHeight, floorArea, source, originX, originZ, id)
+	if err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dimensions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id": id, "width": width, "depth": depth, "ceiling_height": ceilingHeight,
+		"floor_area": floorArea, "dimensions_source": source,
+	})
+}
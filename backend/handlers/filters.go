@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// sqlFilter accumulates WHERE conditions with numbered placeholders.
+type sqlFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition in which every "?" refers to arg.
+func (f *sqlFilter) add(condition string, arg interface{}) {
+	f.conditions = append(f.conditions, strings.ReplaceAll(condition, "?", f.bind(arg)))
+}
+
+// bind registers arg and returns its placeholder, for use outside WHERE.
+func (f *sqlFilter) bind(arg interface{}) string {
+	f.args = append(f.args, arg)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// where renders the conditions as a WHERE clause, or "" when there are none.
+func (f *sqlFilter) where() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// addRanges reads min_<param> and max_<param> query parameters for each
+// param -> column pair and adds the matching bounds.
+func (f *sqlFilter) addRanges(c *gin.Context, columns map[string]string) error {
+	for param, column := range columns {
+		for _, bound := range []struct{ prefix, op string }{{"min_", ">="}, {"max_", "<="}} {
+			raw := c.Query(bound.prefix + param)
+			if raw == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %q", bound.prefix, param, raw)
+			}
+			f.add(column+" "+bound.op+" ?", value)
+		}
+	}
+	return nil
+}
+
+// furnitureDimensionColumns are the dimension filters accepted by the catalog.
+var furnitureDimensionColumns = map[string]string{
+	"width":  "width",
+	"depth":  "depth",
+	"height": "height",
+}
+
+// roomDimensionColumns are the dimension filters accepted by the room list.
+var roomDimensionColumns = map[string]string{
+	"width":          "width",
+	"depth":          "depth",
+	"ceiling_height": "ceiling_height",
+	"floor_area":     "floor_area",
+}
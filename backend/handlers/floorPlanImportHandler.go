@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"backend/floorplan"
+	"backend/models"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// parseDrawingPoint reads an "x,y" point in drawing units.
+func parseDrawingPoint(s string) (floorplan.Point, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return floorplan.Point{}, errors.New("expected x,y")
+	}
+	x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errX != nil || errY != nil {
+		return floorplan.Point{}, errors.New("expected x,y")
+	}
+	return floorplan.Point{X: x, Y: y}, nil
+}
+
+// importOptions reads the calibration and outline fields of an import form.
+func importOptions(c *gin.Context) (floorplan.ImportOptions, error) {
+	opts := floorplan.ImportOptions{
+		Units:       c.PostForm("units"),
+		DoubleWalls: c.PostForm("walls") == "double",
+	}
+	fields := []string{"scale", "reference_length", "wall_height"}
+	for i, target := range []*float64{&opts.Scale, &opts.RefLength, &opts.WallHeight} {
+		raw := c.PostForm(fields[i])
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 {
+			return opts, fmt.Errorf("Invalid %s", fields[i])
+		}
+		*target = value
+	}
+	if opts.RefLength > 0 {
+		var err error
+		if opts.RefFrom, err = parseDrawingPoint(c.PostForm("reference_from")); err != nil {
+			return opts, fmt.Errorf("Invalid reference_from: %v", err)
+		}
+		if opts.RefTo, err = parseDrawingPoint(c.PostForm("reference_to")); err != nil {
+			return opts, fmt.Errorf("Invalid reference_to: %v", err)
+		}
+	}
+	if layers := c.PostForm("layers"); layers != "" {
+		for _, layer := range strings.Split(layers, ",") {
+			if layer = strings.TrimSpace(layer); layer != "" {
+				opts.Layers = append(opts.Layers, layer)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// ImportFloorPlan reads a floor plan drawing (.svg or .dxf) from a multipart
+// form and returns the room outline found on it as a generate request: a
+// name and a normalized floor plan in metres, ready for POST /rooms/generate
+// after any corrections. Nothing is saved.
+//
+// The scale comes from, in order: scale (metres per drawing unit), a
+// reference_length in metres between the drawing points reference_from and
+// reference_to ("x,y"), units (mm, cm, dm, m, in or ft), or the units
+// declared in the file. layers (comma separated) limits the linework read,
+// walls=double follows the inner line of walls drawn with both faces, and
+// wall_height sets the height of the room.
+func ImportFloorPlan(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Drawing file is required"})
+		return
+	}
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(file.Filename)), ".")
+	if f := c.PostForm("format"); f != "" {
+		format = strings.ToLower(f)
+	}
+	opts, err := importOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read drawing"})
+		return
+	}
+	defer f.Close()
+	var drawing *floorplan.Drawing
+	switch format {
+	case "svg":
+		drawing, err = floorplan.ParseSVG(f)
+	case "dxf":
+		drawing, err = floorplan.ParseDXF(f)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Drawing must be an SVG or DXF file"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, scale, err := floorplan.Import(drawing, opts)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(file.Filename), path.Ext(file.Filename))
+	}
+	c.JSON(http.StatusOK, struct {
+		Name string `json:"name"`
+		models.FloorPlan
+		// Scale is the drawing unit in metres that was used.
+		Scale float64 `json:"scale"`
+		Area  float64 `json:"floor_area"`
+	}{name, plan, scale, floorplan.Area(plan.Floor)})
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/convert"
+	"backend/db"
+	"backend/mesh"
+	"backend/models"
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// furnitureColumns is the catalog column list read by scanFurniture.
+const furnitureColumns = `id, COALESCE(name, ''), obj_file_path, texture_path, thumbnail_path,
+	vertex_count, face_count, bounds_width, bounds_height, bounds_depth, texture_files, units,
+	width, depth, height, dimensions_source, COALESCE(category, ''), tags,
+	price, currency, COALESCE(sku, ''), supplier, glb_file_path`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFurniture reads a row selected with furnitureColumns and converts the
+// stored file paths to asset URLs.
+func scanFurniture(row rowScanner) (models.Furniture, error) {
+	var f models.Furniture
+	err := row.Scan(&f.ID, &f.Name, &f.ObjFilePath, &f.TexturePath, &f.ThumbnailPath,
+		&f.VertexCount, &f.FaceCount, &f.BoundsWidth, &f.BoundsHeight, &f.BoundsDepth,
+		pq.Array(&f.TextureFiles), &f.Units,
+		&f.Width, &f.Depth, &f.Height, &f.DimensionsSource, &f.Category, pq.Array(&f.Tags),
+		&f.Price, &f.Currency, &f.SKU, &f.Supplier, &f.GlbFilePath)
+	if err != nil {
+		return f, err
+	}
+	f.ObjFilePath = assetURL(f.ObjFilePath)
+	f.TexturePath = assetURL(f.TexturePath)
+	f.ThumbnailPath = assetURL(f.ThumbnailPath)
+	f.GlbFilePath = assetURL(f.GlbFilePath)
+	f.ModelURL, f.ModelFormat = chooseModel(modelFormatOBJ, f.ObjFilePath, f.GlbFilePath)
+	return f, nil
+}
+
+// parseUploadedModel parses an uploaded OBJ and its optional MTL and checks
+// that every material library and texture the model references was uploaded.
+func parseUploadedModel(objFile, mtlFile *multipart.FileHeader, textures []*multipart.FileHeader) (*mesh.Mesh, map[string]*mesh.Material, error) {
+	f, err := objFile.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	model, err := mesh.ParseOBJ(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid OBJ file: %w", err)
+	}
+
+	materials := map[string]*mesh.Material{}
+	if mtlFile != nil {
+		mf, err := mtlFile.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer mf.Close()
+		if materials, err = mesh.ParseMTL(mf); err != nil {
+			return nil, nil, fmt.Errorf("invalid MTL file: %w", err)
+		}
+	}
+
+	for _, lib := range model.MaterialLibs {
+		if mtlFile == nil || path.Base(filepath.ToSlash(lib)) != mtlFile.Filename {
+			return nil, nil, fmt.Errorf("OBJ references material library %q which was not uploaded", lib)
+		}
+	}
+	uploaded := map[string]bool{}
+	for _, t := range textures {
+		uploaded[t.Filename] = true
+	}
+	for _, tex := range mesh.TextureFiles(materials) {
+		if !uploaded[path.Base(tex)] {
+			return nil, nil, fmt.Errorf("MTL references texture %q which was not uploaded", tex)
+		}
+	}
+	return model, materials, nil
+}
+
+// UploadFurniture adds a furniture item to the catalog from a multipart form
+// with fields name, obj, and optionally mtl, textures (repeatable), texture,
+// thumbnail, category, tags, price, currency, sku, supplier and
+// width/depth/height overrides in metres. The model is validated and its
+// metadata recorded.
+func UploadFurniture(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Furniture name is required"})
+		return
+	}
+	objFile, err := c.FormFile("obj")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OBJ file is required"})
+		return
+	}
+	mtlFile, _ := c.FormFile("mtl")
+	textureFile, _ := c.FormFile("texture")
+	thumbnailFile, _ := c.FormFile("thumbnail")
+
+	var textures []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		textures = form.File["textures"]
+	}
+	if textureFile != nil {
+		textures = append(textures, textureFile)
+	}
+
+	model, materials, err := parseUploadedModel(objFile, mtlFile, textures)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	meta := mesh.Inspect(model, materials)
+
+	// Real-world dimensions come from the model unless given explicitly.
+	width, depth, height := metresFromBounds(meta)
+	origin := modelOrigin(meta)
+	source := "model"
+	for _, field := range []struct {
+		name   string
+		target *float64
+	}{{"width", &width}, {"depth", &depth}, {"height", &height}} {
+		raw := c.PostForm(field.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + field.name})
+			return
+		}
+		*field.target = value
+		source = "manual"
+	}
+
+	category := sql.NullString{String: strings.ToLower(strings.TrimSpace(c.PostForm("category")))}
+	if category.String != "" {
+		exists, err := categoryExists(category.String)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown category: " + category.String})
+			return
+		}
+		category.Valid = true
+	}
+
+	price := 0.0
+	if raw := c.PostForm("price"); raw != "" {
+		if price, err = strconv.ParseFloat(raw, 64); err != nil || price < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price"})
+			return
+		}
+	}
+	currency, err := normalizeCurrency(c.PostForm("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	files := append([]*multipart.FileHeader{objFile}, textures...)
+	if mtlFile != nil {
+		files = append(files, mtlFile)
+	}
+	if thumbnailFile != nil {
+		files = append(files, thumbnailFile)
+	}
+	stored, refs, err := storeUploadedBlobs(c.Request.Context(), files)
+	if err != nil {
+		log.Printf("Error storing uploaded files: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store files"})
+		return
+	}
+
+	furniture := models.Furniture{
+		Name:             name,
+		ObjFilePath:      stored[path.Base(objFile.Filename)].Key,
+		VertexCount:      meta.VertexCount,
+		FaceCount:        meta.FaceCount,
+		BoundsWidth:      meta.Bounds.Size().X,
+		BoundsHeight:     meta.Bounds.Size().Y,
+		BoundsDepth:      meta.Bounds.Size().Z,
+		TextureFiles:     meta.Textures,
+		Units:            meta.Units,
+		Width:            width,
+		Depth:            depth,
+		Height:           height,
+		DimensionsSource: source,
+		Category:         category.String,
+		Tags:             normalizeTags(c.PostFormArray("tags")),
+		Price:            price,
+		Currency:         currency,
+		SKU:              strings.TrimSpace(c.PostForm("sku")),
+		Supplier:         strings.TrimSpace(c.PostForm("supplier")),
+	}
+	furniture.TexturePath = modelTexture(stored, textureFile, materials)
+	if thumbnailFile != nil {
+		furniture.ThumbnailPath = stored[path.Base(thumbnailFile.Filename)].Key
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO furniture (name, obj_file_path, texture_path, thumbnail_path,
+		                       vertex_count, face_count, bounds_width, bounds_height, bounds_depth, texture_files, units,
+		                       width, depth, height, dimensions_source, category, tags,
+		                       price, currency, sku, supplier, origin_x, origin_y, origin_z)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+		        $22, $23, $24)
+		RETURNING id`,
+		furniture.Name, furniture.ObjFilePath, furniture.TexturePath, furniture.ThumbnailPath,
+		furniture.VertexCount, furniture.FaceCount, furniture.BoundsWidth, furniture.BoundsHeight,
+		furniture.BoundsDepth, pq.Array(furniture.TextureFiles), furniture.Units,
+		furniture.Width, furniture.Depth, furniture.Height, furniture.DimensionsSource,
+		category, pq.Array(furniture.Tags),
+		furniture.Price, furniture.Currency, nullableSKU(furniture.SKU), furniture.Supplier,
+		origin.X, origin.Y, origin.Z,
+	).Scan(&furniture.ID)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU is already used by another item"})
+		return
+	}
+	if err != nil {
+		log.Printf("Database insert error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add furniture: " + err.Error()})
+		return
+	}
+	if err := blobs.Attach(c.Request.Context(), tx, blobs.OwnerFurniture, furniture.ID, refs); err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Database insert error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add furniture: " + err.Error()})
+		return
+	}
+
+	furniture.GlbFilePath = convertFurnitureModel(c.Request.Context(), furniture.ID)
+	furniture.LODs = []models.FurnitureLOD{}
+	if furniture.FaceCount >= convert.MinLODFaces {
+		furniture.LODs = generateFurnitureLODs(c.Request.Context(), furniture.ID)
+	}
+	if furniture.ThumbnailPath == "" {
+		furniture.ThumbnailPath = renderFurnitureThumbnail(c.Request.Context(), furniture.ID)
+	}
+
+	furniture.ObjFilePath = assetURL(furniture.ObjFilePath)
+	furniture.TexturePath = assetURL(furniture.TexturePath)
+	furniture.ThumbnailPath = assetURL(furniture.ThumbnailPath)
+	furniture.GlbFilePath = assetURL(furniture.GlbFilePath)
+	furniture.ModelURL, furniture.ModelFormat = chooseModel(preferredModelFormat(c), furniture.ObjFilePath, furniture.GlbFilePath)
+	c.JSON(http.StatusCreated, furniture)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"archive/zip"
+	"backend/db"
+	"backend/importer"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ImportFurniture bulk-imports catalog items from a multipart form with a
+// manifest file (.csv or .json) and an archive (.zip) holding the files it
+// references. Rows are upserted by SKU; dry_run=true only validates.
+func ImportFurniture(c *gin.Context) {
+	manifestFile, err := c.FormFile("manifest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Manifest file is required"})
+		return
+	}
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(manifestFile.Filename)), ".")
+	if f := c.PostForm("format"); f != "" {
+		format = f
+	}
+
+	mf, err := manifestFile.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read manifest"})
+		return
+	}
+	defer mf.Close()
+	rows, err := importer.ParseManifest(mf, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var archive *zip.Reader
+	if archiveFile, err := c.FormFile("archive"); err == nil {
+		af, err := archiveFile.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read archive"})
+			return
+		}
+		defer af.Close()
+		if archive, err = zip.NewReader(af, archiveFile.Size); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ZIP archive: " + err.Error()})
+			return
+		}
+	}
+
+	im := importer.Importer{
+		DB:        db.DB,
+		Blobs:     assetBlobs(),
+		DryRun:    c.PostForm("dry_run") == "true",
+		Converter: modelConverter(),
+	}
+	report, err := im.Run(c.Request.Context(), rows, archive)
+	if err != nil {
+		log.Printf("Import error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
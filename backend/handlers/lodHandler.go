@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/models"
+	"github.com/lib/pq"
+)
+
+// attachLODs loads the levels of detail of every item in one query.
+func attachLODs(items []models.Furniture) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(items))
+	byID := map[int]*models.Furniture{}
+	for i := range items {
+		ids[i] = int64(items[i].ID)
+		items[i].LODs = []models.FurnitureLOD{}
+		byID[items[i].ID] = &items[i]
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT furniture_id, level, face_count, glb_file_path, min_distance
+		FROM furniture_lod WHERE furniture_id = ANY($1) ORDER BY furniture_id, level`,
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var furnitureID int
+		var lod models.FurnitureLOD
+		if err := rows.Scan(&furnitureID, &lod.Level, &lod.FaceCount, &lod.URL, &lod.MinDistance); err != nil {
+			return err
+		}
+		lod.URL = assetURL(lod.URL)
+		if item, ok := byID[furnitureID]; ok {
+			item.LODs = append(item.LODs, lod)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"backend/convert"
+	"backend/db"
+	"backend/models"
+	"context"
+	"github.com/gin-gonic/gin"
+	"log"
+	"strings"
+)
+
+// Model formats clients can ask the catalog endpoints for.
+const (
+	modelFormatOBJ = "obj"
+	modelFormatGLB = "glb"
+)
+
+// preferredModelFormat reads the client's model format preference from the
+// format query parameter or an Accept header naming model/gltf-binary.
+// OBJ is the default so existing clients keep working.
+func preferredModelFormat(c *gin.Context) string {
+	switch strings.ToLower(c.Query("format")) {
+	case modelFormatGLB:
+		return modelFormatGLB
+	case modelFormatOBJ:
+		return modelFormatOBJ
+	}
+	if strings.Contains(c.GetHeader("Accept"), "model/gltf-binary") {
+		return modelFormatGLB
+	}
+	return modelFormatOBJ
+}
+
+// chooseModel picks the model URL for a format, falling back to the OBJ when
+// no GLB has been produced yet.
+func chooseModel(format, objURL, glbURL string) (string, string) {
+	if format == modelFormatGLB && glbURL != "" {
+		return glbURL, modelFormatGLB
+	}
+	return objURL, modelFormatOBJ
+}
+
+// applyModelFormat sets the model URL of catalog items to the client's
+// preferred format.
+func applyModelFormat(c *gin.Context, items []models.Furniture) {
+	format := preferredModelFormat(c)
+	for i := range items {
+		items[i].ModelURL, items[i].ModelFormat = chooseModel(format, items[i].ObjFilePath, items[i].GlbFilePath)
+	}
+}
+
+// modelConverter returns the OBJ to GLB converter on the configured storage.
+func modelConverter() *convert.Converter {
+	return &convert.Converter{DB: db.DB, Blobs: assetBlobs()}
+}
+
+// convertFurnitureModel produces the GLB of a catalog item. A failed
+// conversion only logs: the OBJ stays usable and the backfill job retries.
+func convertFurnitureModel(ctx context.Context, id int) string {
+	key, err := modelConverter().Furniture(ctx, id)
+	if err != nil {
+		log.Printf("GLB conversion of furniture %d failed: %v", id, err)
+		return ""
+	}
+	return key
+}
+
+// generateFurnitureLODs builds the levels of detail of a heavy catalog item.
+// Like conversion, a failure only logs and is retried by the backfill job.
+func generateFurnitureLODs(ctx context.Context, id int) []models.FurnitureLOD {
+	lods, err := modelConverter().FurnitureLODs(ctx, id)
+	if err != nil {
+		log.Printf("LOD generation for furniture %d failed: %v", id, err)
+	}
+	result := []models.FurnitureLOD{}
+	for _, lod := range lods {
+		result = append(result, models.FurnitureLOD{
+			Level: lod.Level, FaceCount: lod.FaceCount, URL: assetURL(lod.Key), MinDistance: lod.MinDistance,
+		})
+	}
+	return result
+}
+
+// renderFurnitureThumbnail renders a thumbnail for a catalog item uploaded
+// without one. A failure leaves the item without a thumbnail.
+func renderFurnitureThumbnail(ctx context.Context, id int) string {
+	key, err := modelConverter().FurnitureThumbnail(ctx, id)
+	if err != nil {
+		log.Printf("Thumbnail rendering for furniture %d failed: %v", id, err)
+		return ""
+	}
+	return key
+}
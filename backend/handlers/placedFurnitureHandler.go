@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"backend/db"
+	"backend/mesh"
 	"backend/models"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -12,7 +14,13 @@ import (
 )
 
 func GetAllFurniture(c *gin.Context) {
-	rows, err := db.DB.Query("SELECT * FROM furniture")
+	var filter sqlFilter
+	if err := filter.addRanges(c, furnitureDimensionColumns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.DB.Query("SELECT "+furnitureColumns+" FROM furniture"+filter.where(), filter.args...)
 	if err != nil {
 		log.Printf("Database query error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
@@ -27,18 +35,11 @@ func GetAllFurniture(c *gin.Context) {
 
 	var furnitures []models.Furniture
 	for rows.Next() {
-		var furniture models.Furniture
-		err := rows.Scan(&furniture.ID, &furniture.Name, &furniture.ObjFilePath, &furniture.TexturePath,
-			&furniture.ThumbnailPath)
-
+		furniture, err := scanFurniture(rows)
 		if err != nil {
 			log.Printf("Row scan error: %v", err)
 			continue
 		}
-
-		furniture.ObjFilePath = transformAssetPath(furniture.ObjFilePath)
-		furniture.TexturePath = transformAssetPath(furniture.TexturePath)
-		furniture.ThumbnailPath = transformAssetPath(furniture.ThumbnailPath)
 		furnitures = append(furnitures, furniture)
 	}
 
@@ -48,7 +49,103 @@ func GetAllFurniture(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, furnitures)
+	if err = attachVariants(furnitures); err != nil {
+		log.Printf("Variant query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+	if err = attachLODs(furnitures); err != nil {
+		log.Printf("LOD query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+	applyModelFormat(c, furnitures)
+
+	respondCachedJSON(c, furnitures)
+}
+
+// placedFurnitureSelect joins a placement with its catalog item and the chosen
+// variant, if any. Callers append the WHERE clause.
+const placedFurnitureSelect = `
+	SELECT
+		pf.id, pf.project_id, pf.furniture_id, pf.x, pf.y, pf.z,
+		pf.rotation_x, pf.rotation, pf.rotation_z, pf.scale_x, pf.scale_y, pf.scale_z,
+		f.id, f.name, f.obj_file_path, f.texture_path, f.thumbnail_path,
+		v.id, COALESCE(v.name, ''), COALESCE(v.material, ''), COALESCE(v.color, ''), COALESCE(v.finish, ''),
+		COALESCE(v.texture_path, ''), COALESCE(v.mtl_file_path, ''), COALESCE(v.thumbnail_path, '')
+	FROM "PlacedFurniture" pf
+	JOIN furniture f ON pf.furniture_id = f.id
+	LEFT JOIN furniture_variant v ON pf.variant_id = v.id
+`
+
+// scanPlacedFurniture reads a row selected with placedFurnitureSelect. When a
+// variant is chosen its texture replaces the catalog item's default texture.
+func scanPlacedFurniture(row rowScanner) (models.PlacedFurniture, error) {
+	var pf models.PlacedFurniture
+	var variantID sql.NullInt64
+	var variant models.FurnitureVariant
+	err := row.Scan(
+		&pf.ID, &pf.ProjectID, &pf.FurnitureID,
+		&pf.X, &pf.Y, &pf.Z,
+		&pf.RotationX, &pf.Rotation, &pf.RotationZ, &pf.ScaleX, &pf.ScaleY, &pf.ScaleZ,
+		&pf.Furniture.ID, &pf.Furniture.Name,
+		&pf.Furniture.ObjFilePath, &pf.Furniture.TexturePath,
+		&pf.Furniture.ThumbnailPath,
+		&variantID, &variant.Name, &variant.Material, &variant.Color, &variant.Finish,
+		&variant.TexturePath, &variant.MtlFilePath, &variant.ThumbnailPath,
+	)
+	if err != nil {
+		return pf, err
+	}
+	pf.Quaternion = placementTransform(pf).Rotation.Quaternion()
+	if variantID.Valid {
+		variant.ID = int(variantID.Int64)
+		variant.FurnitureID = pf.FurnitureID
+		pf.VariantID = &variant.ID
+		pf.Variant = &variant
+		if variant.TexturePath != "" {
+			pf.Furniture.TexturePath = variant.TexturePath
+		}
+		variant.TexturePath = assetURL(variant.TexturePath)
+		variant.MtlFilePath = assetURL(variant.MtlFilePath)
+		variant.ThumbnailPath = assetURL(variant.ThumbnailPath)
+	}
+	pf.Furniture.ObjFilePath = assetURL(pf.Furniture.ObjFilePath)
+	pf.Furniture.TexturePath = assetURL(pf.Furniture.TexturePath)
+	pf.Furniture.ThumbnailPath = assetURL(pf.Furniture.ThumbnailPath)
+	return pf, nil
+}
+
+// maxPlacementScale bounds how far a placed model may be stretched.
+const maxPlacementScale = 100
+
+// placementTransform is how a placement puts its model in the room.
+func placementTransform(pf models.PlacedFurniture) mesh.Transform {
+	return mesh.Transform{
+		Position: mesh.Vec3{X: pf.X, Y: pf.Y, Z: pf.Z},
+		Rotation: mesh.Euler{X: pf.RotationX, Y: pf.Rotation, Z: pf.RotationZ},
+		Scale:    mesh.Vec3{X: pf.ScaleX, Y: pf.ScaleY, Z: pf.ScaleZ},
+	}
+}
+
+// normalizeTransform checks the transform of a placement request and fills
+// it in: a quaternion, when sent, replaces the Euler angles, and scales
+// left at 0 become 1.
+func normalizeTransform(pf *models.PlacedFurniture) error {
+	if pf.Quaternion != ([4]float64{}) {
+		e := mesh.EulerFromQuaternion(pf.Quaternion)
+		pf.RotationX, pf.Rotation, pf.RotationZ = e.X, e.Y, e.Z
+	}
+	for _, scale := range []*float64{&pf.ScaleX, &pf.ScaleY, &pf.ScaleZ} {
+		if *scale == 0 {
+			*scale = 1
+		}
+		if *scale < 0 || *scale > maxPlacementScale {
+			return fmt.Errorf("scale must be greater than 0 and at most %d", maxPlacementScale)
+		}
+	}
+	pf.Quaternion = placementTransform(*pf).Rotation.Quaternion()
+	return nil
 }
 
 func GetPlacedFurnitureByProject(c *gin.Context) {
@@ -59,14 +156,7 @@ func GetPlacedFurnitureByProject(c *gin.Context) {
 		return
 	}
 
-	query := `
-       SELECT 
-          pf.id, pf.project_id, pf.furniture_id, pf.x, pf.y, pf.z, pf.rotation,
-          f.id, f.name, f.obj_file_path, f.texture_path, f.thumbnail_path
-       FROM "PlacedFurniture" pf
-       JOIN furniture f ON pf.furniture_id = f.id
-       WHERE pf.project_id = $1
-    `
+	query := placedFurnitureSelect + `WHERE pf.project_id = $1`
 
 	rows, err := db.DB.Query(query, projectID)
 	if err != nil {
@@ -83,14 +173,7 @@ func GetPlacedFurnitureByProject(c *gin.Context) {
 
 	var placedFurnitureList []models.PlacedFurniture
 	for rows.Next() {
-		var pf models.PlacedFurniture
-		err := rows.Scan(
-			&pf.ID, &pf.ProjectID, &pf.FurnitureID,
-			&pf.X, &pf.Y, &pf.Z, &pf.Rotation,
-			&pf.Furniture.ID, &pf.Furniture.Name,
-			&pf.Furniture.ObjFilePath, &pf.Furniture.TexturePath,
-			&pf.Furniture.ThumbnailPath,
-		)
+		pf, err := scanPlacedFurniture(rows)
 		if err != nil {
 			log.Printf("Row scan error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan furniture: " + err.Error()})
@@ -108,7 +191,10 @@ func GetPlacedFurnitureByProject(c *gin.Context) {
 	c.JSON(http.StatusOK, placedFurnitureList)
 }
 
-// UpdateFurniturePosition updates the position and rotation of a placed furniture item
+// UpdateFurniturePosition updates the position, rotation and scale of a
+// placed furniture item. Fields left out keep their stored values, so clients
+// that only turn items about the vertical axis can keep sending x, y, z and
+// rotation. The new placement is checked like in AddPlacedFurniture.
 func UpdateFurniturePosition(c *gin.Context) {
 	// Get the furniture ID from the path parameter
 	furnitureIDStr := c.Param("id")
@@ -120,25 +206,80 @@ func UpdateFurniturePosition(c *gin.Context) {
 
 	// Parse the request body
 	var updateData struct {
-		X        float64 `json:"x"`
-		Y        float64 `json:"y"`
-		Z        float64 `json:"z"`
-		Rotation float64 `json:"rotation"`
+		X          *float64    `json:"x"`
+		Y          *float64    `json:"y"`
+		Z          *float64    `json:"z"`
+		Rotation   *float64    `json:"rotation"`
+		RotationX  *float64    `json:"rotation_x"`
+		RotationZ  *float64    `json:"rotation_z"`
+		Quaternion *[4]float64 `json:"quaternion"`
+		ScaleX     *float64    `json:"scale_x"`
+		ScaleY     *float64    `json:"scale_y"`
+		ScaleZ     *float64    `json:"scale_z"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
 		return
 	}
+	strict, ok := strictPlacement(c)
+	if !ok {
+		return
+	}
+
+	current, err := scanPlacedFurniture(db.DB.QueryRow(placedFurnitureSelect+`WHERE pf.id = $1`, furnitureID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving furniture details: " + err.Error()})
+		}
+		return
+	}
+	current.Quaternion = [4]float64{}
+	if updateData.Quaternion != nil {
+		current.Quaternion = *updateData.Quaternion
+	}
+	for _, field := range []struct {
+		value  *float64
+		target *float64
+	}{
+		{updateData.X, &current.X}, {updateData.Y, &current.Y}, {updateData.Z, &current.Z},
+		{updateData.Rotation, &current.Rotation}, {updateData.RotationX, &current.RotationX},
+		{updateData.RotationZ, &current.RotationZ}, {updateData.ScaleX, &current.ScaleX},
+		{updateData.ScaleY, &current.ScaleY}, {updateData.ScaleZ, &current.ScaleZ},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	if err := normalizeTransform(&current); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conflicts, err := placementConflicts(current)
+	if err != nil {
+		log.Printf("Placement check error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check placement: " + err.Error()})
+		return
+	}
+	if strict && len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Placement conflicts with the room or other items", "conflicts": conflicts})
+		return
+	}
 
-	// Update the furniture position and rotation in the database
+	// Update the furniture transform in the database
 	updateQuery := `
         UPDATE "PlacedFurniture"
-        SET x = $1, y = $2, z = $3, rotation = $4
-        WHERE id = $5
+        SET x = $1, y = $2, z = $3, rotation_x = $4, rotation = $5, rotation_z = $6,
+            scale_x = $7, scale_y = $8, scale_z = $9
+        WHERE id = $10
     `
 
-	_, err = db.DB.Exec(updateQuery, updateData.X, updateData.Y, updateData.Z, updateData.Rotation, furnitureID)
+	_, err = db.DB.Exec(updateQuery, current.X, current.Y, current.Z,
+		current.RotationX, current.Rotation, current.RotationZ,
+		current.ScaleX, current.ScaleY, current.ScaleZ, furnitureID)
 	if err != nil {
 		log.Printf("Database update error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update furniture position and rotation: " + err.Error()})
@@ -146,32 +287,21 @@ func UpdateFurniturePosition(c *gin.Context) {
 	}
 
 	// Fetch the updated furniture to return to the client
-	query := `
-        SELECT 
-            pf.id, pf.project_id, pf.furniture_id, pf.x, pf.y, pf.z, pf.rotation,
-            f.id, f.name, f.obj_file_path, f.texture_path, f.thumbnail_path
-        FROM "PlacedFurniture" pf
-        JOIN furniture f ON pf.furniture_id = f.id
-        WHERE pf.id = $1
-    `
+	query := placedFurnitureSelect + `WHERE pf.id = $1`
 
 	row := db.DB.QueryRow(query, furnitureID)
 
-	var updatedFurniture models.PlacedFurniture
-	err = row.Scan(
-		&updatedFurniture.ID, &updatedFurniture.ProjectID, &updatedFurniture.FurnitureID,
-		&updatedFurniture.X, &updatedFurniture.Y, &updatedFurniture.Z, &updatedFurniture.Rotation,
-		&updatedFurniture.Furniture.ID, &updatedFurniture.Furniture.Name,
-		&updatedFurniture.Furniture.ObjFilePath, &updatedFurniture.Furniture.TexturePath,
-		&updatedFurniture.Furniture.ThumbnailPath,
-	)
+	updatedFurniture, err := scanPlacedFurniture(row)
 
 	if err != nil {
 		log.Printf("Database query error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve updated furniture: " + err.Error()})
 		return
 	}
+	projectPreviews.schedule(updatedFurniture.ProjectID)
 
+	updatedFurniture.Conflicts = conflicts
+	updatedFurniture.Warnings = conflictWarnings(conflicts)
 	c.JSON(http.StatusOK, updatedFurniture)
 }
 
@@ -186,25 +316,11 @@ func DeletePlacedFurniture(c *gin.Context) {
 	}
 
 	// Store furniture details before deletion to return to client
-	query := `
-        SELECT 
-            pf.id, pf.project_id, pf.furniture_id, pf.x, pf.y, pf.z, pf.rotation,
-            f.id, f.name, f.obj_file_path, f.texture_path, f.thumbnail_path
-        FROM "PlacedFurniture" pf
-        JOIN furniture f ON pf.furniture_id = f.id
-        WHERE pf.id = $1
-    `
+	query := placedFurnitureSelect + `WHERE pf.id = $1`
 
 	row := db.DB.QueryRow(query, furnitureID)
 
-	var deletedFurniture models.PlacedFurniture
-	err = row.Scan(
-		&deletedFurniture.ID, &deletedFurniture.ProjectID, &deletedFurniture.FurnitureID,
-		&deletedFurniture.X, &deletedFurniture.Y, &deletedFurniture.Z, &deletedFurniture.Rotation,
-		&deletedFurniture.Furniture.ID, &deletedFurniture.Furniture.Name,
-		&deletedFurniture.Furniture.ObjFilePath, &deletedFurniture.Furniture.TexturePath,
-		&deletedFurniture.Furniture.ThumbnailPath,
-	)
+	deletedFurniture, err := scanPlacedFurniture(row)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -236,6 +352,7 @@ func DeletePlacedFurniture(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
 		return
 	}
+	projectPreviews.schedule(deletedFurniture.ProjectID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":          "Furniture deleted successfully",
@@ -243,7 +360,10 @@ func DeletePlacedFurniture(c *gin.Context) {
 	})
 }
 
-// AddPlacedFurniture adds new furniture to a project
+// AddPlacedFurniture adds new furniture to a project. The placement is
+// checked against the other items, the room's walls and the space its doors
+// need: with validation=strict conflicts are rejected with 409, otherwise
+// the item is placed and the conflicts come back as warnings.
 func AddPlacedFurniture(c *gin.Context) {
 	var newFurniture models.PlacedFurniture
 
@@ -253,22 +373,59 @@ func AddPlacedFurniture(c *gin.Context) {
 		return
 	}
 
+	if err := validateVariant(newFurniture.VariantID, newFurniture.FurnitureID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeTransform(&newFurniture); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	strict, ok := strictPlacement(c)
+	if !ok {
+		return
+	}
+	newFurniture.ID = 0
+	conflicts, err := placementConflicts(newFurniture)
+	if err != nil {
+		log.Printf("Placement check error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check placement: " + err.Error()})
+		return
+	}
+	if strict && len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Placement conflicts with the room or other items", "conflicts": conflicts})
+		return
+	}
+
+	// Work out the cost impact before the item is counted in the project's spend
+	impact, warnings, err := budgetImpact(newFurniture.ProjectID, newFurniture.FurnitureID)
+	if err != nil {
+		log.Printf("Budget check error: %v", err)
+	}
+
 	// Insert new furniture into database
 	insertQuery := `
-        INSERT INTO "PlacedFurniture" (project_id, furniture_id, x, y, z, rotation)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO "PlacedFurniture" (project_id, furniture_id, x, y, z,
+                                       rotation_x, rotation, rotation_z, scale_x, scale_y, scale_z, variant_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         RETURNING id
     `
 
 	var insertedID int
-	err := db.DB.QueryRow(
+	err = db.DB.QueryRow(
 		insertQuery,
 		newFurniture.ProjectID,
 		newFurniture.FurnitureID,
 		newFurniture.X,
 		newFurniture.Y,
 		newFurniture.Z,
+		newFurniture.RotationX,
 		newFurniture.Rotation,
+		newFurniture.RotationZ,
+		newFurniture.ScaleX,
+		newFurniture.ScaleY,
+		newFurniture.ScaleZ,
+		newFurniture.VariantID,
 	).Scan(&insertedID)
 
 	if err != nil {
@@ -278,25 +435,11 @@ func AddPlacedFurniture(c *gin.Context) {
 	}
 
 	// Fetch the complete furniture details to return
-	query := `
-        SELECT 
-            pf.id, pf.project_id, pf.furniture_id, pf.x, pf.y, pf.z, pf.rotation,
-            f.id, f.name, f.obj_file_path, f.texture_path, f.thumbnail_path
-        FROM "PlacedFurniture" pf
-        JOIN furniture f ON pf.furniture_id = f.id
-        WHERE pf.id = $1
-    `
+	query := placedFurnitureSelect + `WHERE pf.id = $1`
 
 	row := db.DB.QueryRow(query, insertedID)
 
-	var insertedFurniture models.PlacedFurniture
-	err = row.Scan(
-		&insertedFurniture.ID, &insertedFurniture.ProjectID, &insertedFurniture.FurnitureID,
-		&insertedFurniture.X, &insertedFurniture.Y, &insertedFurniture.Z, &insertedFurniture.Rotation,
-		&insertedFurniture.Furniture.ID, &insertedFurniture.Furniture.Name,
-		&insertedFurniture.Furniture.ObjFilePath, &insertedFurniture.Furniture.TexturePath,
-		&insertedFurniture.Furniture.ThumbnailPath,
-	)
+	insertedFurniture, err := scanPlacedFurniture(row)
 
 	if err != nil {
 		log.Printf("Database query error: %v", err)
@@ -304,5 +447,10 @@ func AddPlacedFurniture(c *gin.Context) {
 		return
 	}
 
+	projectPreviews.schedule(insertedFurniture.ProjectID)
+
+	insertedFurniture.BudgetImpact = impact
+	insertedFurniture.Conflicts = conflicts
+	insertedFurniture.Warnings = append(warnings, conflictWarnings(conflicts)...)
 	c.JSON(http.StatusCreated, insertedFurniture)
 }
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/mesh"
+	"backend/models"
+	"backend/placement"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// strictPlacement reads how a placement request is checked from the
+// validation query parameter: "lenient", the default, accepts conflicting
+// placements and lists the conflicts as warnings; "strict" rejects them. On
+// an unknown value it writes the error response and returns false.
+func strictPlacement(c *gin.Context) (strict, ok bool) {
+	switch c.Query("validation") {
+	case "", "lenient":
+		return false, true
+	case "strict":
+		return true, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": `validation must be "strict" or "lenient"`})
+	return false, false
+}
+
+// catalogModelColumns are the furniture columns read into a catalogModel,
+// for a query naming the furniture table f.
+const catalogModelColumns = `f.width, f.height, f.depth, f.origin_x, f.origin_y, f.origin_z`
+
+// catalogModel is the size of a catalog item and where its model lies
+// around its origin, when known.
+type catalogModel struct {
+	size                      mesh.Vec3
+	originX, originY, originZ sql.NullFloat64
+}
+
+// fields returns the scan destinations for catalogModelColumns.
+func (m *catalogModel) fields() []any {
+	return []any{&m.size.X, &m.size.Y, &m.size.Z, &m.originX, &m.originY, &m.originZ}
+}
+
+// bounds returns the box the model fills around its origin. Items whose
+// origin is not recorded are taken to be centred on it and standing on it.
+func (m catalogModel) bounds() mesh.Box {
+	lo := mesh.Vec3{X: -m.size.X / 2, Z: -m.size.Z / 2}
+	if m.originX.Valid && m.originY.Valid && m.originZ.Valid {
+		lo = mesh.Vec3{X: m.originX.Float64, Y: m.originY.Float64, Z: m.originZ.Float64}
+	}
+	return mesh.Box{Min: lo, Max: mesh.Vec3{X: lo.X + m.size.X, Y: lo.Y + m.size.Y, Z: lo.Z + m.size.Z}}
+}
+
+// loadCatalogModel reads the model size and origin of a catalog item.
+func loadCatalogModel(furnitureID int) (catalogModel, error) {
+	var m catalogModel
+	err := db.DB.QueryRow(`SELECT `+catalogModelColumns+` FROM furniture f WHERE f.id = $1`, furnitureID).Scan(m.fields()...)
+	return m, err
+}
+
+// placementConflicts checks a placement against the room of its project and
+// the project's other placements. pf.ID is left out of the others, so an
+// item being moved does not run into itself.
+func placementConflicts(pf models.PlacedFurniture) ([]models.PlacementConflict, error) {
+	model, err := loadCatalogModel(pf.FurnitureID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	box := placement.Footprint(placementTransform(pf), model.bounds())
+
+	room, err := projectRoom(pf.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	others, err := projectFootprints(pf.ProjectID, pf.ID)
+	if err != nil {
+		return nil, err
+	}
+	return placement.Check(box, others, room), nil
+}
+
+// projectRoom returns the room of a project for placement checks, or nil
+// when the project has no room with known bounds.
+func projectRoom(projectID int) (*placement.Room, error) {
+	var roomID int
+	err := db.DB.QueryRow(`SELECT COALESCE(room_layout_id, 0) FROM projects WHERE id = $1`, projectID).Scan(&roomID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || roomID == 0 {
+		return nil, err
+	}
+	_, plan, openings, err := loadRoomGeometry(roomID)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	return &placement.Room{Plan: *plan, Openings: openings}, nil
+}
+
+// projectFootprints returns the boxes of the items placed in a project,
+// except the placement exceptID.
+func projectFootprints(projectID, exceptID int) ([]placement.Box, error) {
+	rows, err := db.DB.Query(`
+		SELECT pf.id, COALESCE(f.name, ''), pf.x, pf.y, pf.z, pf.rotation_x, pf.rotation, pf.rotation_z,
+		       pf.scale_x, pf.scale_y, pf.scale_z, `+catalogModelColumns+`
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		WHERE pf.project_id = $1 AND pf.id <> $2
+		ORDER BY pf.id`, projectID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var boxes []placement.Box
+	for rows.Next() {
+		var name string
+		var pf models.PlacedFurniture
+		var model catalogModel
+		err := rows.Scan(append([]any{&pf.ID, &name, &pf.X, &pf.Y, &pf.Z, &pf.RotationX, &pf.Rotation, &pf.RotationZ,
+			&pf.ScaleX, &pf.ScaleY, &pf.ScaleZ}, model.fields()...)...)
+		if err != nil {
+			return nil, err
+		}
+		box := placement.Footprint(placementTransform(pf), model.bounds())
+		box.ID, box.Name = pf.ID, name
+		boxes = append(boxes, box)
+	}
+	return boxes, rows.Err()
+}
+
+// conflictWarnings describes placement conflicts for the warnings of a
+// response.
+func conflictWarnings(conflicts []models.PlacementConflict) []string {
+	var warnings []string
+	for _, conflict := range conflicts {
+		warnings = append(warnings, placement.Describe(conflict))
+	}
+	return warnings
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/placement"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// defaultSnapReach is how far, in metres, items are pulled to walls and
+// other items when the request does not say.
+const defaultSnapReach = 0.3
+
+// snapRequest is a proposed placement with the snapping settings. ID is the
+// placement being moved, if it is already placed, so it is not snapped to
+// itself.
+type snapRequest struct {
+	models.PlacedFurniture
+	Grid      float64  `json:"grid"`       // metres; 0 for no grid
+	AngleStep float64  `json:"angle_step"` // degrees; 0 for any angle
+	Reach     *float64 `json:"reach"`      // metres; defaults to defaultSnapReach
+}
+
+// SnapPlacement snaps a proposed transform for an item in a project: against
+// the nearest wall, facing into the room, onto the grid and in line with
+// nearby items. Nothing is saved; the snapped placement comes back with the
+// snaps made and any conflicts left, for the editor to show or apply.
+func SnapPlacement(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	if _, ok := loadUserProject(c, projectID); !ok {
+		return
+	}
+
+	var req snapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	reach := defaultSnapReach
+	if req.Reach != nil {
+		reach = *req.Reach
+	}
+	if req.Grid < 0 || req.AngleStep < 0 || reach < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grid, angle_step and reach must not be negative"})
+		return
+	}
+	pf := req.PlacedFurniture
+	pf.ProjectID = projectID
+	if err := normalizeTransform(&pf); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	model, err := loadCatalogModel(pf.FurnitureID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading furniture %d: %v", pf.FurnitureID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load furniture"})
+		return
+	}
+	room, err := projectRoom(projectID)
+	if err != nil {
+		log.Printf("Error loading room of project %d: %v", projectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load room"})
+		return
+	}
+	others, err := projectFootprints(projectID, pf.ID)
+	if err != nil {
+		log.Printf("Error loading placements of project %d: %v", projectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load placed furniture"})
+		return
+	}
+
+	box := placement.Footprint(placementTransform(pf), model.bounds())
+	snapped, snaps := placement.Snap(box, others, room, placement.SnapOptions{
+		Grid:      req.Grid,
+		AngleStep: req.AngleStep * math.Pi / 180,
+		Reach:     reach,
+	})
+	if turn := math.Remainder(snapped.Rotation-box.Rotation, 2*math.Pi); math.Abs(turn) > 1e-9 {
+		e := placementTransform(pf).Rotation.TurnY(turn)
+		pf.RotationX, pf.Rotation, pf.RotationZ = e.X, e.Y, e.Z
+		pf.Quaternion = e.Quaternion()
+	}
+	// Models need not be centred on their origin, so the position moves by
+	// as much as the centre of the turned footprint has to.
+	turned := placement.Footprint(placementTransform(pf), model.bounds())
+	pf.X += snapped.X - turned.X
+	pf.Z += snapped.Z - turned.Z
+
+	conflicts := placement.Check(snapped, others, room)
+	if snaps == nil {
+		snaps = []models.PlacementSnap{}
+	}
+	if conflicts == nil {
+		conflicts = []models.PlacementConflict{}
+	}
+	c.JSON(http.StatusOK, models.SnappedPlacement{Placement: pf, Snaps: snaps, Conflicts: conflicts})
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"backend/db"
+	"backend/floorplan"
+	"backend/mesh"
+	"backend/models"
+	"backend/placement"
+	"backend/plan"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// loadRoomGeometry reads the name, outline and openings of a room. Rooms
+// without a floor plan get their width x depth rectangle, starting where
+// the bounds of their model do since the editor loads the model as it is;
+// catalog rooms not measured since their origin was recorded have no
+// outline.
+func loadRoomGeometry(roomID int) (string, *models.FloorPlan, []models.RoomOpening, error) {
+	var name string
+	var width, depth, ceilingHeight float64
+	var originX, originZ sql.NullFloat64
+	var planJSON []byte
+	err := db.DB.QueryRow(`
+		SELECT COALESCE(name, ''), width, depth, ceiling_height, origin_x, origin_z, floor_plan
+		FROM room WHERE id = $1`,
+		roomID).Scan(&name, &width, &depth, &ceilingHeight, &originX, &originZ, &planJSON)
+	if err != nil {
+		return name, nil, nil, err
+	}
+	room := models.FloorPlan{WallHeight: ceilingHeight, WallThickness: floorplan.DefaultWallThickness}
+	if planJSON != nil {
+		if err := json.Unmarshal(planJSON, &room); err != nil {
+			return name, nil, nil, err
+		}
+	} else if width > 0 && depth > 0 && originX.Valid && originZ.Valid {
+		room.Floor = floorplan.RectangleOutline(width, depth)
+		for i := range room.Floor {
+			room.Floor[i].X += originX.Float64
+			room.Floor[i].Z += originZ.Float64
+		}
+	} else {
+		return name, nil, nil, nil
+	}
+	openings, err := loadRoomOpenings(roomID)
+	return name, &room, openings, err
+}
+
+// loadProjectPlan gathers the room outline, openings and furniture
+// footprints of a project.
+func loadProjectPlan(project models.Project) (plan.Plan, error) {
+	p := plan.Plan{Title: project.Name}
+	if project.Room != 0 {
+		var err error
+		if p.RoomName, p.Room, p.Openings, err = loadRoomGeometry(project.Room); err != nil {
+			return p, err
+		}
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT COALESCE(f.name, ''), pf.x, pf.y, pf.z, pf.rotation_x, pf.rotation, pf.rotation_z,
+		       pf.scale_x, pf.scale_y, pf.scale_z, `+catalogModelColumns+`
+		FROM "PlacedFurniture" pf
+		JOIN furniture f ON pf.furniture_id = f.id
+		WHERE pf.project_id = $1
+		ORDER BY pf.id`, project.ID)
+	if err != nil {
+		return p, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var label string
+		var pf models.PlacedFurniture
+		var model catalogModel
+		err := rows.Scan(append([]any{&label, &pf.X, &pf.Y, &pf.Z, &pf.RotationX, &pf.Rotation, &pf.RotationZ,
+			&pf.ScaleX, &pf.ScaleY, &pf.ScaleZ}, model.fields()...)...)
+		if err != nil {
+			return p, err
+		}
+		p.Items = append(p.Items, planItem(label, placementTransform(pf), model.bounds()))
+	}
+	return p, rows.Err()
+}
+
+// planItem is the footprint of a model with the given bounds placed by t.
+func planItem(label string, t mesh.Transform, bounds mesh.Box) plan.Item {
+	box := placement.Footprint(t, bounds)
+	return plan.Item{Label: label, X: box.X, Z: box.Z, Rotation: box.Rotation, Width: box.Width, Depth: box.Depth}
+}
+
+// projectPlanSheet lays out the plan of the project in the path for the
+// authenticated user. On failure it writes the error response and returns
+// nil.
+func projectPlanSheet(c *gin.Context) *plan.Sheet {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return nil
+	}
+	project, ok := loadUserProject(c, projectID)
+	if !ok {
+		return nil
+	}
+	p, err := loadProjectPlan(project)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load project plan: " + err.Error()})
+		return nil
+	}
+	return plan.Layout(p)
+}
+
+// GetProjectPlanSVG returns a dimensioned floor plan of a project as SVG.
+func GetProjectPlanSVG(c *gin.Context) {
+	sheet := projectPlanSheet(c)
+	if sheet == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := sheet.WriteSVG(&buf); err != nil {
+		log.Printf("Error writing plan SVG: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to draw plan"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="project-%s-plan.svg"`, c.Param("id")))
+	c.Data(http.StatusOK, "image/svg+xml", buf.Bytes())
+}
+
+// GetProjectPlanPDF returns a dimensioned floor plan of a project as a one
+// page PDF.
+func GetProjectPlanPDF(c *gin.Context) {
+	sheet := projectPlanSheet(c)
+	if sheet == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := sheet.WritePDF(&buf); err != nil {
+		log.Printf("Error writing plan PDF: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to draw plan"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="project-%s-plan.pdf"`, c.Param("id")))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"backend/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultCurrency is used when a catalog item is priced without a currency.
+const defaultCurrency = "EUR"
+
+// normalizeCurrency upper-cases a currency code and checks it is ISO 4217 shaped.
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return defaultCurrency, nil
+	}
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("invalid currency %q", currency)
+	}
+	return currency, nil
+}
+
+// nullableSKU stores empty SKUs as NULL so the unique index ignores them.
+func nullableSKU(sku string) sql.NullString {
+	sku = strings.TrimSpace(sku)
+	return sql.NullString{String: sku, Valid: sku != ""}
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// UpdateFurniturePricing sets the price, currency, SKU and supplier of a
+// catalog item.
+func UpdateFurniturePricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+
+	var body struct {
+		Price    float64 `json:"price"`
+		Currency string  `json:"currency"`
+		SKU      string  `json:"sku"`
+		Supplier string  `json:"supplier"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+	if body.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Price cannot be negative"})
+		return
+	}
+	currency, err := normalizeCurrency(body.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	row := db.DB.QueryRow(`
+		UPDATE furniture SET price = $1, currency = $2, sku = $3, supplier = $4
+		WHERE id = $5
+		RETURNING `+furnitureColumns,
+		body.Price, currency, nullableSKU(body.SKU), strings.TrimSpace(body.Supplier), id)
+	furniture, err := scanFurniture(row)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		case isUniqueViolation(err):
+			c.JSON(http.StatusConflict, gin.H{"error": "SKU is already used by another item"})
+		default:
+			log.Printf("Database update error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pricing: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, furniture)
+}
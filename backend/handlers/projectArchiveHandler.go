@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"archive/zip"
+	"backend/blobs"
+	"backend/db"
+	"backend/floorplan"
+	"backend/importer"
+	"backend/models"
+	"backend/storage"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// projectArchiveManifest is the name of the manifest inside a project archive.
+const projectArchiveManifest = "project.json"
+
+// maxArchiveManifestSize bounds how much of project.json is read.
+const maxArchiveManifestSize = 16 << 20
+
+// ExportProjectArchive returns a project of the authenticated user as a
+// portable ZIP archive described by models.ProjectArchive. With assets=true
+// the model files of its catalog items are included, so a server that lacks
+// them can add them to its catalog on import.
+func ExportProjectArchive(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	project, ok := loadUserProject(c, projectID)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeProjectArchive(c.Request.Context(), &buf, project, c.Query("assets") == "true"); err != nil {
+		log.Printf("Error exporting project %d: %v", projectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export project: " + err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d.zip"`, projectID))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// writeProjectArchive writes the archive of a project to w.
+func writeProjectArchive(ctx context.Context, w io.Writer, project models.Project, withAssets bool) error {
+	archive := models.ProjectArchive{
+		Format:     models.ProjectArchiveFormat,
+		Version:    models.ProjectArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Project: models.ArchivedProject{
+			Name:           project.Name,
+			Description:    project.Description,
+			Budget:         project.Budget,
+			BudgetCurrency: project.BudgetCurrency,
+		},
+		Catalog:    []models.ArchivedFurniture{},
+		Placements: []models.ArchivedPlacement{},
+	}
+
+	if project.Room != 0 {
+		room := models.ArchivedRoom{ID: project.Room}
+		var planJSON []byte
+		err := db.DB.QueryRowContext(ctx, `SELECT COALESCE(name, ''), width, depth, ceiling_height, floor_plan FROM room WHERE id = $1`,
+			project.Room).Scan(&room.Name, &room.Width, &room.Depth, &room.CeilingHeight, &planJSON)
+		if err != nil {
+			return err
+		}
+		if planJSON != nil {
+			room.FloorPlan = &models.FloorPlan{}
+			if err := json.Unmarshal(planJSON, room.FloorPlan); err != nil {
+				return err
+			}
+			if room.Openings, err = loadRoomOpenings(project.Room); err != nil {
+				return err
+			}
+		}
+		archive.Room = &room
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT pf.furniture_id, COALESCE(v.name, ''), pf.x, pf.y, pf.z,
+		       pf.rotation_x, pf.rotation, pf.rotation_z, pf.scale_x, pf.scale_y, pf.scale_z
+		FROM "PlacedFurniture" pf
+		LEFT JOIN furniture_variant v ON pf.variant_id = v.id
+		WHERE pf.project_id = $1
+		ORDER BY pf.id`, project.ID)
+	if err != nil {
+		return err
+	}
+	var furnitureIDs []int64
+	seen := map[int]bool{}
+	for rows.Next() {
+		var p models.ArchivedPlacement
+		err := rows.Scan(&p.Furniture, &p.Variant, &p.X, &p.Y, &p.Z,
+			&p.RotationX, &p.Rotation, &p.RotationZ, &p.ScaleX, &p.ScaleY, &p.ScaleZ)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		archive.Placements = append(archive.Placements, p)
+		if !seen[p.Furniture] {
+			seen[p.Furniture] = true
+			furnitureIDs = append(furnitureIDs, int64(p.Furniture))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	rows, err = db.DB.QueryContext(ctx, `
+		SELECT id, COALESCE(sku, ''), COALESCE(name, ''), COALESCE(category, ''), tags, width, depth, height,
+		       price, currency, supplier, obj_file_path, COALESCE(texture_path, ''), COALESCE(thumbnail_path, '')
+		FROM furniture WHERE id = ANY($1) ORDER BY id`, pq.Array(furnitureIDs))
+	if err != nil {
+		return err
+	}
+	type modelFiles struct{ obj, texture, thumbnail string }
+	var files []modelFiles
+	for rows.Next() {
+		var item models.ArchivedFurniture
+		var f modelFiles
+		err := rows.Scan(&item.ID, &item.SKU, &item.Name, &item.Category, pq.Array(&item.Tags),
+			&item.Width, &item.Depth, &item.Height, &item.Price, &item.Currency, &item.Supplier,
+			&f.obj, &f.texture, &f.thumbnail)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		item.Currency = strings.TrimSpace(item.Currency)
+		archive.Catalog = append(archive.Catalog, item)
+		files = append(files, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if withAssets {
+		for i := range archive.Catalog {
+			f := files[i]
+			if err := archiveFurnitureFiles(ctx, zw, &archive.Catalog[i], f.obj, f.texture, f.thumbnail); err != nil {
+				return fmt.Errorf("furniture %d: %w", archive.Catalog[i].ID, err)
+			}
+		}
+	}
+
+	manifest, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	mw, err := zw.Create(projectArchiveManifest)
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// archiveFurnitureFiles adds the model files of a catalog item to an archive
+// under assets/furniture/<id>/ and points the item at them. Files keep the
+// names they were uploaded under so MTL and texture references still
+// resolve; GLB conversions are left out, as the importing server makes its
+// own.
+func archiveFurnitureFiles(ctx context.Context, zw *zip.Writer, item *models.ArchivedFurniture, objKey, textureKey, thumbnailKey string) error {
+	refs, err := blobs.Refs(ctx, db.DB, blobs.OwnerFurniture, item.ID)
+	if err != nil {
+		return err
+	}
+	dir := fmt.Sprintf("assets/furniture/%d/", item.ID)
+	names := map[string]string{} // archive path by storage key
+	for name, blob := range refs {
+		if strings.ToLower(path.Ext(name)) != ".glb" {
+			names[blob.Key] = dir + name
+		}
+	}
+	for _, key := range []string{objKey, textureKey, thumbnailKey} {
+		if key = storage.NormalizeKey(key); key != "" && names[key] == "" {
+			names[key] = dir + path.Base(key)
+		}
+	}
+
+	keys := make([]string, 0, len(names))
+	for key := range names {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	files := assetBlobs().Files
+	for _, key := range keys {
+		rc, err := files.Open(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Leaving missing file %s of furniture %d out of the archive", key, item.ID)
+			delete(names, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fw, err := zw.Create(names[key])
+		if err == nil {
+			_, err = io.Copy(fw, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	item.Obj = names[storage.NormalizeKey(objKey)]
+	item.Texture = names[storage.NormalizeKey(textureKey)]
+	item.Thumbnail = names[storage.NormalizeKey(thumbnailKey)]
+	return nil
+}
+
+// readProjectArchive reads and checks the manifest of a project archive.
+func readProjectArchive(archive *zip.Reader) (models.ProjectArchive, error) {
+	var manifest models.ProjectArchive
+	f, err := archive.Open(projectArchiveManifest)
+	if err != nil {
+		return manifest, fmt.Errorf("archive has no %s", projectArchiveManifest)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxArchiveManifestSize))
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid %s: %w", projectArchiveManifest, err)
+	}
+	if manifest.Format != models.ProjectArchiveFormat {
+		return manifest, fmt.Errorf("not a project archive")
+	}
+	if manifest.Version < 1 || manifest.Version > models.ProjectArchiveVersion {
+		return manifest, fmt.Errorf("unsupported project archive version %d", manifest.Version)
+	}
+	return manifest, nil
+}
+
+// ImportProjectArchive creates a project for the authenticated user from an
+// archive made by ExportProjectArchive, sent as the multipart field archive.
+// An optional name replaces the archived project name. Catalog items are
+// matched by SKU, or by name when they have none; missing items whose model
+// files are in the archive are added to the catalog unless
+// create_missing=false. Generated rooms are generated again for the user.
+// Anything still missing is listed in the report and its placements skipped.
+func ImportProjectArchive(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	archiveFile, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive file is required"})
+		return
+	}
+	af, err := archiveFile.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read archive"})
+		return
+	}
+	defer af.Close()
+	archive, err := zip.NewReader(af, archiveFile.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ZIP archive: " + err.Error()})
+		return
+	}
+	manifest, err := readProjectArchive(archive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := models.Project{
+		User:        int(userID.(float64)),
+		Name:        strings.TrimSpace(manifest.Project.Name),
+		Description: manifest.Project.Description,
+		Budget:      manifest.Project.Budget,
+	}
+	if name := strings.TrimSpace(c.PostForm("name")); name != "" {
+		project.Name = name
+	}
+	if project.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		return
+	}
+	if project.Budget != nil && *project.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget cannot be negative"})
+		return
+	}
+	if project.BudgetCurrency, err = normalizeCurrency(manifest.Project.BudgetCurrency); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check every placement before anything is written, so a bad archive
+	// leaves nothing behind.
+	transforms := make([]models.PlacedFurniture, len(manifest.Placements))
+	for i, p := range manifest.Placements {
+		transforms[i] = models.PlacedFurniture{
+			X: p.X, Y: p.Y, Z: p.Z,
+			RotationX: p.RotationX, Rotation: p.Rotation, RotationZ: p.RotationZ,
+			ScaleX: p.ScaleX, ScaleY: p.ScaleY, ScaleZ: p.ScaleZ,
+		}
+		if err := normalizeTransform(&transforms[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid placement %d in archive: %v", i+1, err)})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	report := models.ProjectImportReport{
+		FurnitureIDs:     map[int]int{},
+		CreatedFurniture: []int{},
+		Missing:          []models.MissingArchiveItem{},
+	}
+	generatedPlan, err := matchArchivedRoom(ctx, manifest.Room, &project, &report)
+	if err != nil {
+		log.Printf("Error importing archived room: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project: " + err.Error()})
+		return
+	}
+	if err := matchArchivedFurniture(ctx, manifest, archive, c.PostForm("create_missing") != "false", &report); err != nil {
+		log.Printf("Error matching archived furniture: %v", err)
+		discardCreatedFurniture(report.CreatedFurniture)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project: " + err.Error()})
+		return
+	}
+
+	// Resolve placements before writing anything to the project.
+	type variantKey struct {
+		furnitureID int
+		name        string
+	}
+	variants := map[variantKey]*int{}
+	missingVariants := map[variantKey]int{} // index into report.Missing
+	var placements []models.PlacedFurniture
+	for i, p := range manifest.Placements {
+		furnitureID, ok := report.FurnitureIDs[p.Furniture]
+		if !ok {
+			report.Skipped++
+			continue
+		}
+		placed := transforms[i]
+		placed.FurnitureID = furnitureID
+		if p.Variant != "" {
+			key := variantKey{furnitureID, p.Variant}
+			variantID, ok := variants[key]
+			if !ok {
+				var id int
+				err := db.DB.QueryRowContext(ctx, `
+					SELECT id FROM furniture_variant WHERE furniture_id = $1 AND name = $2 ORDER BY id LIMIT 1`,
+					furnitureID, p.Variant).Scan(&id)
+				switch {
+				case err == nil:
+					variantID = &id
+				case errors.Is(err, sql.ErrNoRows):
+				default:
+					log.Printf("Database query error: %v", err)
+					discardCreatedFurniture(report.CreatedFurniture)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project: " + err.Error()})
+					return
+				}
+				variants[key] = variantID
+			}
+			if variantID == nil {
+				// The item is placed with its default finish instead.
+				i, ok := missingVariants[key]
+				if !ok {
+					i = len(report.Missing)
+					missingVariants[key] = i
+					report.Missing = append(report.Missing, models.MissingArchiveItem{
+						Type: "variant", ID: p.Furniture, Name: p.Variant,
+						Reason: "the item has no finish with this name; the default finish is used",
+					})
+				}
+				report.Missing[i].Placements++
+			}
+			placed.VariantID = variantID
+		}
+		placements = append(placements, placed)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		discardCreatedFurniture(report.CreatedFurniture)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	if generatedPlan != nil {
+		project.Room, err = insertGeneratedRoom(ctx, tx, project.User, manifest.Room.Name, *generatedPlan, manifest.Room.Openings)
+	}
+	if err == nil {
+		err = tx.QueryRow(`
+			INSERT INTO projects (user_id, name, description, room_layout_id, budget, budget_currency)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6) RETURNING id`,
+			project.User, project.Name, project.Description, project.Room, project.Budget, project.BudgetCurrency,
+		).Scan(&project.ID)
+	}
+	for i := 0; err == nil && i < len(placements); i++ {
+		p := placements[i]
+		_, err = tx.Exec(`
+			INSERT INTO "PlacedFurniture" (project_id, furniture_id, x, y, z,
+			                               rotation_x, rotation, rotation_z, scale_x, scale_y, scale_z, variant_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			project.ID, p.FurnitureID, p.X, p.Y, p.Z,
+			p.RotationX, p.Rotation, p.RotationZ, p.ScaleX, p.ScaleY, p.ScaleZ, p.VariantID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Database insert error: %v", err)
+		discardCreatedFurniture(report.CreatedFurniture)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project: " + err.Error()})
+		return
+	}
+	report.Placed = len(placements)
+	report.Project = project
+
+	if generatedPlan != nil {
+		finishRoomModel(ctx, project.Room, true)
+	}
+	if project.Room != 0 || len(placements) > 0 {
+		projectPreviews.schedule(project.ID)
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// matchArchivedFurniture maps the catalog items of an archive to this
+// server's, adding missing ones from the archived files when create is set.
+// Items that stay missing are added to the report with the number of
+// placements they leave out.
+func matchArchivedFurniture(ctx context.Context, manifest models.ProjectArchive, archive *zip.Reader, create bool, report *models.ProjectImportReport) error {
+	uses := map[int]int{}
+	for _, p := range manifest.Placements {
+		uses[p.Furniture]++
+	}
+	missing := func(item models.ArchivedFurniture, reason string) {
+		report.Missing = append(report.Missing, models.MissingArchiveItem{
+			Type: "furniture", ID: item.ID, SKU: item.SKU, Name: item.Name, Placements: uses[item.ID], Reason: reason,
+		})
+	}
+
+	var rows []importer.Row
+	var pending []models.ArchivedFurniture
+	described := map[int]bool{}
+	for _, item := range manifest.Catalog {
+		described[item.ID] = true
+		var id int
+		var err error
+		if item.SKU != "" {
+			err = db.DB.QueryRowContext(ctx, "SELECT id FROM furniture WHERE sku = $1", item.SKU).Scan(&id)
+		} else {
+			err = db.DB.QueryRowContext(ctx, "SELECT id FROM furniture WHERE name = $1 ORDER BY id LIMIT 1", item.Name).Scan(&id)
+		}
+		if err == nil {
+			report.FurnitureIDs[item.ID] = id
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		switch {
+		case !create:
+			missing(item, "not in the catalog")
+		case item.Obj == "":
+			missing(item, "not in the catalog and the archive has no model files for it")
+		case item.SKU == "":
+			missing(item, "not in the catalog and has no SKU to add it under")
+		default:
+			category := item.Category
+			if category != "" {
+				var known bool
+				err := db.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM furniture_category WHERE slug = $1)", category).Scan(&known)
+				if err != nil {
+					return err
+				}
+				if !known {
+					category = ""
+				}
+			}
+			rows = append(rows, importer.Row{
+				Line: len(rows) + 1, SKU: item.SKU, Name: item.Name,
+				Obj: item.Obj, Texture: item.Texture, Thumbnail: item.Thumbnail,
+				Category: category, Tags: item.Tags, Price: item.Price, Currency: item.Currency,
+				Supplier: item.Supplier, Width: item.Width, Depth: item.Depth, Height: item.Height,
+			})
+			pending = append(pending, item)
+		}
+	}
+	for id, count := range uses {
+		if !described[id] {
+			report.Missing = append(report.Missing, models.MissingArchiveItem{
+				Type: "furniture", ID: id, Placements: count, Reason: "not described in the archive",
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	im := importer.Importer{DB: db.DB, Blobs: assetBlobs(), Converter: modelConverter()}
+	result, err := im.Run(ctx, rows, archive)
+	if err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		item := pending[row.Row-1]
+		if row.FurnitureID == 0 {
+			missing(item, "could not be added to the catalog: "+strings.Join(row.Errors, "; "))
+			continue
+		}
+		report.FurnitureIDs[item.ID] = row.FurnitureID
+		report.CreatedFurniture = append(report.CreatedFurniture, row.FurnitureID)
+	}
+	return nil
+}
+
+// matchArchivedRoom sets the room of an imported project to the catalog
+// room of the same name. For a generated room it returns the checked floor
+// plan instead, to generate the room again for the user along with the
+// project.
+func matchArchivedRoom(ctx context.Context, room *models.ArchivedRoom, project *models.Project, report *models.ProjectImportReport) (*models.FloorPlan, error) {
+	if room == nil {
+		return nil, nil
+	}
+	missing := func(reason string) {
+		report.Missing = append(report.Missing, models.MissingArchiveItem{
+			Type: "room", ID: room.ID, Name: room.Name, Reason: reason,
+		})
+	}
+
+	if room.FloorPlan != nil {
+		plan, err := floorplan.Normalize(*room.FloorPlan)
+		if err == nil {
+			err = floorplan.ValidateOpenings(plan.Floor, plan.WallHeight, room.Openings)
+		}
+		if err != nil {
+			missing("invalid floor plan: " + err.Error())
+			return nil, nil
+		}
+		return &plan, nil
+	}
+
+	err := db.DB.QueryRowContext(ctx, "SELECT id FROM room WHERE owner_id IS NULL AND name = $1 ORDER BY id LIMIT 1",
+		room.Name).Scan(&project.Room)
+	if errors.Is(err, sql.ErrNoRows) {
+		missing("no catalog room with this name")
+		return nil, nil
+	}
+	return nil, err
+}
+
+// discardCreatedFurniture removes the catalog items added for an import
+// that then failed. Their files are released with them.
+func discardCreatedFurniture(ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	if _, err := db.DB.Exec(`DELETE FROM furniture WHERE id = ANY($1)`, pq.Array(ids64)); err != nil {
+		log.Printf("Error removing furniture %v added for a failed import: %v", ids, err)
+	}
+}
@@ -8,6 +8,23 @@ import (
 	"net/http"
 )
 
+// projectColumns is the column list read by scanProject, for a table aliased p.
+const projectColumns = `p.id, p.user_id, p.name, COALESCE(p.description, ''), COALESCE(p.room_layout_id, 0),
+	p.budget, p.budget_currency, p.preview_path`
+
+// scanProject reads a row selected with projectColumns.
+func scanProject(row rowScanner) (models.Project, error) {
+	var project models.Project
+	var budget sql.NullFloat64
+	err := row.Scan(&project.ID, &project.User, &project.Name, &project.Description, &project.Room,
+		&budget, &project.BudgetCurrency, &project.ThumbnailURL)
+	if budget.Valid {
+		project.Budget = &budget.Float64
+	}
+	project.ThumbnailURL = assetURL(project.ThumbnailURL)
+	return project, err
+}
+
 func GetProjectsByUser(c *gin.Context) {
 	// get username from middleware
 	username, exists := c.Get("username")
@@ -18,7 +35,7 @@ func GetProjectsByUser(c *gin.Context) {
 
 	// query to fetch projects of the user
 	rows, err := db.DB.Query(`
-		SELECT p.id, p.user_id, p.name, p.description, p.room_layout_id
+		SELECT `+projectColumns+`
 		FROM projects p
 		JOIN users u ON p.user_id = u.id
 		WHERE u.username = $1
@@ -32,8 +49,8 @@ func GetProjectsByUser(c *gin.Context) {
 
 	var projects []models.Project
 	for rows.Next() {
-		var project models.Project
-		if err := rows.Scan(&project.ID, &project.User, &project.Name, &project.Description, &project.Room); err != nil {
+		project, err := scanProject(rows)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -70,11 +87,23 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	if newProject.Budget != nil && *newProject.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Budget cannot be negative"})
+		return
+	}
+	currency, err := normalizeCurrency(newProject.BudgetCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newProject.BudgetCurrency = currency
+
 	// Insert into the database and capture the generated project ID
-	err := db.DB.QueryRow(`
-		INSERT INTO projects (user_id, name, description, room_layout_id)
-		VALUES ($1, $2, $3, $4) RETURNING id`,
+	err = db.DB.QueryRow(`
+		INSERT INTO projects (user_id, name, description, room_layout_id, budget, budget_currency)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
 		newProject.User, newProject.Name, newProject.Description, newProject.Room,
+		newProject.Budget, newProject.BudgetCurrency,
 	).Scan(&newProject.ID) // Capture the generated ID
 
 	if err != nil {
@@ -82,6 +111,8 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	projectPreviews.schedule(newProject.ID)
+
 	// Respond with the created project including the ID
 	c.JSON(http.StatusCreated, newProject)
 }
@@ -97,13 +128,12 @@ func GetProjectByID(c *gin.Context) {
 
 	// Query to fetch the project by its ID
 	row := db.DB.QueryRow(`
-		SELECT p.id, p.user_id, p.name, p.description, p.room_layout_id
+		SELECT `+projectColumns+`
 		FROM projects p
 		WHERE p.id = $1
 	`, projectID)
 
-	var project models.Project
-	err := row.Scan(&project.ID, &project.User, &project.Name, &project.Description, &project.Room)
+	project, err := scanProject(row)
 	if err != nil {
 		// If no project is found or other errors
 		if err == sql.ErrNoRows {
@@ -117,3 +147,32 @@ func GetProjectByID(c *gin.Context) {
 	// Return the project details
 	c.JSON(http.StatusOK, project)
 }
+
+// loadUserProject fetches a project owned by the authenticated user. On
+// failure it writes the error response and returns false.
+func loadUserProject(c *gin.Context, projectID int) (models.Project, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return models.Project{}, false
+	}
+
+	project, err := scanProject(db.DB.QueryRow(`
+		SELECT `+projectColumns+`
+		FROM projects p
+		WHERE p.id = $1
+	`, projectID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return project, false
+	}
+	if uid, ok := userID.(float64); !ok || int(uid) != project.User {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this project"})
+		return project, false
+	}
+	return project, true
+}
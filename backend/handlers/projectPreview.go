@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"backend/convert"
+	"backend/db"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// projectPreviewTimeout bounds a single preview render.
+const projectPreviewTimeout = 2 * time.Minute
+
+// maxPreviewRenders is how many previews are rendered at once. Changing a
+// room queues every project using it, and each render takes a full CPU.
+const maxPreviewRenders = 2
+
+// previewQueue renders project previews in the background. Saves arriving
+// while a project is being rendered are coalesced into one more render, so
+// dragging furniture around does not queue a render per move.
+type previewQueue struct {
+	mu      sync.Mutex
+	running map[int]bool  // project ID -> another render is wanted
+	slots   chan struct{} // held while rendering
+}
+
+var projectPreviews = &previewQueue{running: map[int]bool{}, slots: make(chan struct{}, maxPreviewRenders)}
+
+// schedule queues a preview render of the project.
+func (q *previewQueue) schedule(projectID int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, busy := q.running[projectID]; busy {
+		q.running[projectID] = true
+		return
+	}
+	q.running[projectID] = false
+	go q.run(projectID)
+}
+
+func (q *previewQueue) run(projectID int) {
+	for {
+		q.slots <- struct{}{}
+		ctx, cancel := context.WithTimeout(context.Background(), projectPreviewTimeout)
+		_, err := modelConverter().ProjectPreview(ctx, projectID)
+		cancel()
+		<-q.slots
+		if err != nil && !errors.Is(err, convert.ErrNothingToRender) {
+			log.Printf("Preview rendering for project %d failed: %v", projectID, err)
+		}
+
+		q.mu.Lock()
+		if !q.running[projectID] {
+			delete(q.running, projectID)
+			q.mu.Unlock()
+			return
+		}
+		q.running[projectID] = false
+		q.mu.Unlock()
+	}
+}
+
+// scheduleRoomProjectPreviews queues previews of every project using a room.
+func scheduleRoomProjectPreviews(roomID int) {
+	rows, err := db.DB.Query(`SELECT id FROM projects WHERE room_layout_id = $1`, roomID)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Row scan error: %v", err)
+			return
+		}
+		projectPreviews.schedule(id)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetProjectSceneGLB returns the room and placed furniture of a project as
+// one GLB file, for opening in Blender or an AR viewer.
+func GetProjectSceneGLB(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	if _, ok := loadUserProject(c, projectID); !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := modelConverter().ProjectScene(c.Request.Context(), projectID, &buf); err != nil {
+		log.Printf("Error exporting scene of project %d: %v", projectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export project scene: " + err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d.glb"`, projectID))
+	c.Data(http.StatusOK, "model/gltf-binary", buf.Bytes())
+}
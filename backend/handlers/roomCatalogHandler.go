@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/convert"
+	"backend/db"
+	"backend/mesh"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key error.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// roomUpload holds the files of a room create or update form.
+type roomUpload struct {
+	obj, mtl, texture, thumbnail *multipart.FileHeader
+	textures                     []*multipart.FileHeader // referenced from the MTL
+}
+
+func readRoomUpload(c *gin.Context) roomUpload {
+	var up roomUpload
+	up.obj, _ = c.FormFile("obj")
+	up.mtl, _ = c.FormFile("mtl")
+	up.texture, _ = c.FormFile("texture")
+	up.thumbnail, _ = c.FormFile("thumbnail")
+	if form, err := c.MultipartForm(); err == nil {
+		up.textures = form.File["textures"]
+	}
+	return up
+}
+
+// files lists the uploaded files to store.
+func (up roomUpload) files() []*multipart.FileHeader {
+	var files []*multipart.FileHeader
+	for _, f := range append([]*multipart.FileHeader{up.obj, up.mtl, up.texture, up.thumbnail}, up.textures...) {
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// roomDimensionFields are the form fields overriding measured room dimensions.
+var roomDimensionFields = []string{"width", "depth", "ceiling_height", "floor_area"}
+
+// roomDimensions works out a room's dimensions from its model metadata and
+// the width, depth, ceiling_height and floor_area form fields, which
+// override the measured values. The floor area defaults to width x depth.
+func roomDimensions(c *gin.Context, meta mesh.Metadata) (width, depth, ceilingHeight, floorArea float64, source string, err error) {
+	width, depth, ceilingHeight = metresFromBounds(meta)
+	floorArea = -1
+	source = "model"
+	for i, target := range []*float64{&width, &depth, &ceilingHeight, &floorArea} {
+		raw := c.PostForm(roomDimensionFields[i])
+		if raw == "" {
+			continue
+		}
+		value, parseErr := strconv.ParseFloat(raw, 64)
+		if parseErr != nil || value <= 0 {
+			return 0, 0, 0, 0, "", fmt.Errorf("Invalid %s", roomDimensionFields[i])
+		}
+		*target = value
+		source = "manual"
+	}
+	if floorArea < 0 {
+		floorArea = width * depth
+	}
+	return width, depth, ceilingHeight, floorArea, source, nil
+}
+
+// hasRoomDimensionFields reports whether the form overrides any dimension.
+func hasRoomDimensionFields(c *gin.Context) bool {
+	for _, field := range roomDimensionFields {
+		if c.PostForm(field) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// modelTexture returns the key of the texture used for a room or item: the
+// uploaded texture, or else the first diffuse map of its materials.
+func modelTexture(stored map[string]blobs.Blob, texture *multipart.FileHeader, materials map[string]*mesh.Material) string {
+	if texture != nil {
+		return stored[path.Base(texture.Filename)].Key
+	}
+	for _, mat := range materials {
+		if mat.DiffuseMap != "" {
+			return stored[path.Base(mat.DiffuseMap)].Key
+		}
+	}
+	return ""
+}
+
+// finishRoomModel converts a new or changed room model to GLB and renders a
+// thumbnail when the room has none. Like furniture uploads, failures only log.
+func finishRoomModel(ctx context.Context, id int, renderThumbnail bool) {
+	cv := modelConverter()
+	if _, err := cv.Room(ctx, id); err != nil {
+		log.Printf("GLB conversion of room %d failed: %v", id, err)
+	}
+	if renderThumbnail {
+		if _, err := cv.RoomThumbnail(ctx, id); err != nil {
+			log.Printf("Thumbnail rendering for room %d failed: %v", id, err)
+		}
+	}
+}
+
+// respondRoom writes the stored room with its openings and the given status.
+func respondRoom(c *gin.Context, status, id int) {
+	room, err := scanRoom(db.DB.QueryRow("SELECT "+roomColumns+" FROM room WHERE id = $1", id), preferredModelFormat(c))
+	if err == nil {
+		room.Openings, err = loadRoomOpenings(id)
+	}
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room: " + err.Error()})
+		return
+	}
+	c.JSON(status, room)
+}
+
+// CreateRoom adds a room to the catalog from a multipart form with fields
+// name, obj, and optionally mtl, textures (repeatable), texture, thumbnail and
+// width/depth/ceiling_height/floor_area overrides in metres. Rooms uploaded
+// without a thumbnail get a rendered one. The route is for administrators.
+func CreateRoom(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room name is required"})
+		return
+	}
+	up := readRoomUpload(c)
+	if up.obj == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OBJ file is required"})
+		return
+	}
+	textures := up.textures
+	if up.texture != nil {
+		textures = append(textures, up.texture)
+	}
+	model, materials, err := parseUploadedModel(up.obj, up.mtl, textures)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	meta := mesh.Inspect(model, materials)
+	width, depth, ceilingHeight, floorArea, source, err := roomDimensions(c, meta)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	originX, originZ := roomOrigin(meta)
+
+	stored, refs, err := storeUploadedBlobs(c.Request.Context(), up.files())
+	if err != nil {
+		log.Printf("Error storing uploaded files: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store files"})
+		return
+	}
+	thumbnailPath := ""
+	if up.thumbnail != nil {
+		thumbnailPath = stored[path.Base(up.thumbnail.Filename)].Key
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO room (name, obj_file_path, texture_path, thumbnail_path,
+		                  width, depth, ceiling_height, floor_area, dimensions_source, origin_x, origin_z)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`,
+		name, stored[path.Base(up.obj.Filename)].Key, modelTexture(stored, up.texture, materials), thumbnailPath,
+		width, depth, ceilingHeight, floorArea, source, originX, originZ,
+	).Scan(&id)
+	if err == nil {
+		if err = blobs.Attach(c.Request.Context(), tx, blobs.OwnerRoom, id, refs); err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		log.Printf("Database insert error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add room: " + err.Error()})
+		return
+	}
+
+	finishRoomModel(c.Request.Context(), id, thumbnailPath == "")
+	respondRoom(c, http.StatusCreated, id)
+}
+
+// UpdateRoom changes a catalog room from a multipart form with the same
+// fields as CreateRoom, all optional. A new obj replaces the whole model
+// (with its mtl and textures) and re-measures the room unless dimensions are
+// given; texture and thumbnail can also be replaced on their own. Projects
+// using the room get their previews rendered again. Catalog rooms can only
+// be changed by administrators and generated rooms by their owner; a new
+// obj drops the plan a generated room came from.
+func UpdateRoom(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	var objPath, thumbnailPath string
+	var ownerID sql.NullInt64
+	var hasPlan bool
+	err = db.DB.QueryRow(`SELECT obj_file_path, thumbnail_path, owner_id, floor_plan IS NOT NULL FROM room WHERE id = $1`, id).
+		Scan(&objPath, &thumbnailPath, &ownerID, &hasPlan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+	if !canEditRoom(c, ownerID) {
+		return
+	}
+
+	up := readRoomUpload(c)
+	if up.obj == nil && (up.mtl != nil || len(up.textures) > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mtl and textures can only be replaced together with obj"})
+		return
+	}
+
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if name, ok := c.GetPostForm("name"); ok {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Room name cannot be empty"})
+			return
+		}
+		set("name", name)
+	}
+
+	var meta mesh.Metadata
+	var materials map[string]*mesh.Material
+	if up.obj != nil {
+		textures := up.textures
+		if up.texture != nil {
+			textures = append(textures, up.texture)
+		}
+		var model *mesh.Mesh
+		model, materials, err = parseUploadedModel(up.obj, up.mtl, textures)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		meta = mesh.Inspect(model, materials)
+	} else if hasRoomDimensionFields(c) {
+		if meta, err = measureModel(ctx, objPath); err != nil {
+			log.Printf("Could not measure room model %s: %v", objPath, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Could not measure room model: %v", err)})
+			return
+		}
+	}
+	if up.obj != nil || hasRoomDimensionFields(c) {
+		width, depth, ceilingHeight, floorArea, source, err := roomDimensions(c, meta)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		set("width", width)
+		set("depth", depth)
+		set("ceiling_height", ceilingHeight)
+		set("floor_area", floorArea)
+		set("dimensions_source", source)
+		originX, originZ := roomOrigin(meta)
+		set("origin_x", originX)
+		set("origin_z", originZ)
+	}
+
+	stored, refs, err := storeUploadedBlobs(ctx, up.files())
+	if err != nil {
+		log.Printf("Error storing uploaded files: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store files"})
+		return
+	}
+	modelChanged := up.obj != nil || up.texture != nil
+	if up.obj != nil {
+		set("obj_file_path", stored[path.Base(up.obj.Filename)].Key)
+		set("texture_path", modelTexture(stored, up.texture, materials))
+		if hasPlan {
+			// The plan the room was generated from no longer describes it;
+			// the room is measured from its model like catalog rooms.
+			sets = append(sets, "floor_plan = NULL")
+		}
+	} else if up.texture != nil {
+		set("texture_path", stored[path.Base(up.texture.Filename)].Key)
+	}
+	if modelChanged {
+		// The old GLB no longer matches; it is replaced once converted.
+		set("glb_file_path", "")
+	}
+	if up.thumbnail != nil {
+		set("thumbnail_path", stored[path.Base(up.thumbnail.Filename)].Key)
+	}
+	if len(sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	existing, err := blobs.Refs(ctx, db.DB, blobs.OwnerRoom, id)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	// Rendered thumbnails follow the model; uploaded ones are kept.
+	rendered := thumbnailPath == "" || existing[convert.ThumbnailName].Key == thumbnailPath
+	renderThumbnail := up.thumbnail == nil && rendered && modelChanged
+	if renderThumbnail {
+		// The old thumbnail is released with the old model; the room has
+		// none until the new one is rendered.
+		set("thumbnail_path", "")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE room SET "+strings.Join(sets, ", ")+fmt.Sprintf(" WHERE id = $%d", len(args)+1), append(args, id)...)
+	if err == nil {
+		if up.obj != nil {
+			// A new model replaces every file of the old one except an
+			// uploaded thumbnail that is kept.
+			if up.thumbnail == nil && !rendered {
+				for name, blob := range existing {
+					if blob.Key == thumbnailPath {
+						refs = append(refs, blobs.Ref{Name: name, Hash: blob.Hash})
+					}
+				}
+			}
+			err = blobs.Attach(ctx, tx, blobs.OwnerRoom, id, refs)
+		} else {
+			for _, ref := range refs {
+				if err = blobs.AddRef(ctx, tx, blobs.OwnerRoom, id, ref); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update room: " + err.Error()})
+		return
+	}
+
+	if modelChanged {
+		finishRoomModel(ctx, id, renderThumbnail)
+		scheduleRoomProjectPreviews(id)
+	}
+	respondRoom(c, http.StatusOK, id)
+}
+
+// DeleteRoom removes a room from the catalog. Rooms used by projects are not
+// deleted unless replace_with names another room to move those projects to:
+// a catalog room, or another of the caller's rooms when deleting their own.
+// Like UpdateRoom, it is for administrators or the owner of the room.
+func DeleteRoom(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return
+	}
+	replacement := 0
+	if raw := c.Query("replace_with"); raw != "" {
+		if replacement, err = strconv.Atoi(raw); err != nil || replacement == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid replacement room ID"})
+			return
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	var ownerID sql.NullInt64
+	if err := tx.QueryRow(`SELECT owner_id FROM room WHERE id = $1 FOR UPDATE`, id).Scan(&ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+	if !canEditRoom(c, ownerID) {
+		return
+	}
+
+	var projects []int64
+	if err := tx.QueryRow(`SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM projects WHERE room_layout_id = $1`, id).
+		Scan(pq.Array(&projects)); err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	if len(projects) > 0 {
+		if replacement == 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       fmt.Sprintf("Room is used by %d project(s); pass replace_with to move them to another room", len(projects)),
+				"project_ids": projects,
+			})
+			return
+		}
+		var replacementOwner sql.NullInt64
+		if err := tx.QueryRow(`SELECT owner_id FROM room WHERE id = $1`, replacement).Scan(&replacementOwner); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Replacement room not found"})
+			} else {
+				log.Printf("Database query error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			}
+			return
+		}
+		// Projects only move to a catalog room, or to another room of the
+		// caller's when their own room goes.
+		if replacementOwner.Valid && !(ownsRoom(c, ownerID) && ownsRoom(c, replacementOwner)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Projects can only be moved to a catalog room"})
+			return
+		}
+		if _, err := tx.Exec(`UPDATE projects SET room_layout_id = $1 WHERE room_layout_id = $2`, replacement, id); err != nil {
+			log.Printf("Database update error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move projects: " + err.Error()})
+			return
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM room WHERE id = $1`, id)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if isForeignKeyViolation(err) {
+		// A project picked the room after it was checked.
+		c.JSON(http.StatusConflict, gin.H{"error": "Room is used by a project"})
+		return
+	}
+	if err != nil {
+		log.Printf("Database delete error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete room: " + err.Error()})
+		return
+	}
+
+	for _, projectID := range projects {
+		projectPreviews.schedule(int(projectID))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Room deleted successfully", "moved_project_ids": projects})
+}
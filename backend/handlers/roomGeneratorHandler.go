@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/db"
+	"backend/floorplan"
+	"backend/mesh"
+	"backend/models"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Names under which generated room models are stored.
+const (
+	generatedRoomOBJ = "room.obj"
+	generatedRoomMTL = "room.mtl"
+)
+
+// storeGeneratedRoom builds the model of a normalized floor plan with its
+// openings and stores its OBJ and MTL. It returns the OBJ key and the
+// references to attach.
+func storeGeneratedRoom(ctx context.Context, plan models.FloorPlan, openings []models.RoomOpening) (string, []blobs.Ref, error) {
+	model := floorplan.Build(plan, openings)
+	model.MaterialLibs = []string{generatedRoomMTL}
+
+	var obj, mtl bytes.Buffer
+	if err := mesh.WriteOBJ(&obj, model); err != nil {
+		return "", nil, err
+	}
+	if err := mesh.WriteMTL(&mtl, floorplan.Materials()); err != nil {
+		return "", nil, err
+	}
+	store := assetBlobs()
+	objBlob, err := store.Put(ctx, &obj, generatedRoomOBJ, "model/obj")
+	if err != nil {
+		return "", nil, err
+	}
+	mtlBlob, err := store.Put(ctx, &mtl, generatedRoomMTL, "model/mtl")
+	if err != nil {
+		return "", nil, err
+	}
+	return objBlob.Key, []blobs.Ref{
+		{Name: generatedRoomOBJ, Hash: objBlob.Hash},
+		{Name: generatedRoomMTL, Hash: mtlBlob.Hash},
+	}, nil
+}
+
+// GenerateRoom builds a room from a floor plan and saves it as a room owned by
+// the authenticated user, ready to be used as a project's room layout. The
+// body holds a name, the floor outline as {x, z} corners in metres, and
+// optionally wall_height, wall_thickness and openings. Wall i of an opening
+// runs from corner i to corner i+1 of the normalized outline returned in
+// floor_plan, which drops repeated and collinear corners and lists them
+// counter-clockwise.
+func GenerateRoom(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+		models.FloorPlan
+		Openings []models.RoomOpening `json:"openings"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room name is required"})
+		return
+	}
+	plan, err := floorplan.Normalize(body.FloorPlan)
+	if err == nil {
+		err = floorplan.ValidateOpenings(plan.Floor, plan.WallHeight, body.Openings)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	id, err := createGeneratedRoom(ctx, int(userID.(float64)), body.Name, plan, body.Openings)
+	if err != nil {
+		log.Printf("Error saving generated room: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save room: " + err.Error()})
+		return
+	}
+
+	finishRoomModel(ctx, id, true)
+	respondRoom(c, http.StatusCreated, id)
+}
+
+// createGeneratedRoom stores the model of a normalized floor plan and adds
+// it as a room owned by userID, with its openings, returning the room ID.
+func createGeneratedRoom(ctx context.Context, userID int, name string, plan models.FloorPlan, openings []models.RoomOpening) (int, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := insertGeneratedRoom(ctx, tx, userID, name, plan, openings)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// insertGeneratedRoom is createGeneratedRoom within tx, for callers adding
+// the room together with other rows.
+func insertGeneratedRoom(ctx context.Context, tx *sql.Tx, userID int, name string, plan models.FloorPlan, openings []models.RoomOpening) (int, error) {
+	objKey, refs, err := storeGeneratedRoom(ctx, plan, openings)
+	if err != nil {
+		return 0, err
+	}
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return 0, err
+	}
+	lo, hi := floorplan.Bounds(plan.Floor)
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO room (name, obj_file_path, texture_path, thumbnail_path,
+		                  width, depth, ceiling_height, floor_area, dimensions_source, owner_id, floor_plan)
+		VALUES ($1, $2, '', '', $3, $4, $5, $6, 'model', $7, $8)
+		RETURNING id`,
+		name, objKey, hi.X-lo.X, hi.Z-lo.Z, plan.WallHeight, floorplan.Area(plan.Floor), userID, planJSON,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertRoomOpenings(tx, id, openings); err != nil {
+		return 0, err
+	}
+	if err := blobs.Attach(ctx, tx, blobs.OwnerRoom, id, refs); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetUserRooms lists the rooms generated by the authenticated user.
+func GetUserRooms(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rows, err := db.DB.Query("SELECT "+roomColumns+" FROM room WHERE owner_id = $1 ORDER BY id", int(userID.(float64)))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	format := preferredModelFormat(c)
+	rooms := []models.Room{}
+	for rows.Next() {
+		room, err := scanRoom(rows, format)
+		if err != nil {
+			log.Printf("Row scan error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			return
+		}
+		rooms = append(rooms, room)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Rows iteration error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rooms)
+}
@@ -2,13 +2,80 @@ package handlers
 
 import (
 	"backend/db"
+	"backend/middleware"
 	"backend/models"
 	"database/sql"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
 )
 
+// roomColumns is the room column list read by scanRoom.
+const roomColumns = `id, COALESCE(name, ''), obj_file_path, texture_path, thumbnail_path, glb_file_path,
+	width, depth, ceiling_height, floor_area, dimensions_source, owner_id, floor_plan`
+
+// scanRoom reads a row selected with roomColumns, resolves the stored file
+// paths to asset URLs and picks the model in the requested format.
+func scanRoom(row rowScanner, format string) (models.Room, error) {
+	var room models.Room
+	var ownerID sql.NullInt64
+	var plan []byte
+	err := row.Scan(&room.ID, &room.Name, &room.Object, &room.Texture, &room.Thumbnail, &room.Glb,
+		&room.Width, &room.Depth, &room.CeilingHeight, &room.FloorArea, &room.DimensionsSource,
+		&ownerID, &plan)
+	if err != nil {
+		return room, err
+	}
+	if ownerID.Valid {
+		id := int(ownerID.Int64)
+		room.OwnerID = &id
+	}
+	if plan != nil {
+		room.FloorPlan = &models.FloorPlan{}
+		if err := json.Unmarshal(plan, room.FloorPlan); err != nil {
+			return room, err
+		}
+	}
+	room.Object = assetURL(room.Object)
+	room.Texture = assetURL(room.Texture)
+	room.Thumbnail = assetURL(room.Thumbnail)
+	room.Glb = assetURL(room.Glb)
+	room.ModelURL, room.ModelFormat = chooseModel(format, room.Object, room.Glb)
+	return room, nil
+}
+
+// canEditRoom reports whether the caller may change a room. Catalog rooms
+// are shared and managed by administrators; generated rooms belong to the
+// user who made them. Otherwise it writes the error response and returns
+// false.
+func canEditRoom(c *gin.Context, ownerID sql.NullInt64) bool {
+	if !ownerID.Valid {
+		admin, err := middleware.IsAdmin(c)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			return false
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can change catalog rooms"})
+		}
+		return admin
+	}
+	if ownsRoom(c, ownerID) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this room"})
+	return false
+}
+
+// ownsRoom reports whether a room was generated by the caller.
+func ownsRoom(c *gin.Context, ownerID sql.NullInt64) bool {
+	uid, ok := c.Get("user_id")
+	id, isNumber := uid.(float64)
+	return ok && isNumber && ownerID.Valid && int64(id) == ownerID.Int64
+}
+
 func GetRoomByID(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -16,9 +83,10 @@ func GetRoomByID(c *gin.Context) {
 		return
 	}
 
-	var asset models.Room
-	err := db.DB.QueryRow("SELECT id, name, obj_file_path, texture_path, thumbnail_path FROM room WHERE id = $1", id).
-		Scan(&asset.ID, &asset.Name, &asset.Object, &asset.Thumbnail, &asset.Texture)
+	asset, err := scanRoom(db.DB.QueryRow("SELECT "+roomColumns+" FROM room WHERE id = $1", id), preferredModelFormat(c))
+	if err == nil {
+		asset.Openings, err = loadRoomOpenings(asset.ID)
+	}
 	if err != nil {
 		log.Printf("Database query error: %v", err)
 		if err == sql.ErrNoRows {
@@ -29,21 +97,21 @@ func GetRoomByID(c *gin.Context) {
 		return
 	}
 
-	// Transform the path from the database
-	asset.Object = transformAssetPath(asset.Object)
-	asset.Thumbnail = transformAssetPath(asset.Thumbnail)
-	asset.Texture = transformAssetPath(asset.Texture)
-
-	// Construct the full URL for the OBJ file
-	fullObjURL := "http://localhost:8080/assets/" + asset.Object
-	log.Printf("Serving OBJ file at: %s", fullObjURL)
+	log.Printf("Serving model file at: %s", asset.ModelURL)
 
-	// Return the asset as JSON (you can include the URL or the transformed path)
-	c.JSON(http.StatusOK, asset)
+	respondCachedJSON(c, asset)
 }
 
 func GetAllRooms(c *gin.Context) {
-	rows, err := db.DB.Query("SELECT id, name, obj_file_path, texture_path, thumbnail_path FROM room")
+	var filter sqlFilter
+	if err := filter.addRanges(c, roomDimensionColumns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Rooms generated by users are listed by GetUserRooms.
+	filter.conditions = append(filter.conditions, "owner_id IS NULL")
+
+	rows, err := db.DB.Query("SELECT "+roomColumns+" FROM room"+filter.where(), filter.args...)
 	if err != nil {
 		log.Printf("Database query err error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error" + err.Error()})
@@ -51,18 +119,14 @@ func GetAllRooms(c *gin.Context) {
 	}
 	defer rows.Close()
 
+	format := preferredModelFormat(c)
 	var rooms []models.Room
 	for rows.Next() {
-		var room models.Room
-		err := rows.Scan(&room.ID, &room.Name, &room.Object, &room.Texture, &room.Thumbnail)
+		room, err := scanRoom(rows, format)
 		if err != nil {
 			log.Printf("Row scan error: %v", err)
 			continue
 		}
-
-		room.Object = transformAssetPath(room.Object)
-		room.Texture = transformAssetPath(room.Texture)
-		room.Thumbnail = transformAssetPath(room.Thumbnail)
 		rooms = append(rooms, room)
 	}
 
@@ -72,5 +136,5 @@ func GetAllRooms(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, rooms)
+	respondCachedJSON(c, rooms)
 }
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/db"
+	"backend/floorplan"
+	"backend/models"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// loadRoomOpenings returns the openings of a room, wall by wall.
+func loadRoomOpenings(roomID int) ([]models.RoomOpening, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, type, wall, "offset", width, height, sill_height, swing
+		FROM room_opening WHERE room_id = $1 ORDER BY wall, "offset"`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	openings := []models.RoomOpening{}
+	for rows.Next() {
+		var o models.RoomOpening
+		if err := rows.Scan(&o.ID, &o.Type, &o.Wall, &o.Offset, &o.Width, &o.Height, &o.SillHeight, &o.Swing); err != nil {
+			return nil, err
+		}
+		openings = append(openings, o)
+	}
+	return openings, rows.Err()
+}
+
+// insertRoomOpenings stores openings for a room, setting their IDs.
+func insertRoomOpenings(tx *sql.Tx, roomID int, openings []models.RoomOpening) error {
+	for i := range openings {
+		o := &openings[i]
+		err := tx.QueryRow(`
+			INSERT INTO room_opening (room_id, type, wall, "offset", width, height, sill_height, swing)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+			roomID, o.Type, o.Wall, o.Offset, o.Width, o.Height, o.SillHeight, o.Swing,
+		).Scan(&o.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRoomOpenings replaces the doors, windows and openings of a room with
+// the list in the request body. Generated rooms are rebuilt with the openings
+// cut into their walls; catalog rooms keep their model and the openings are
+// measured against their width x depth rectangle. Generated rooms can only
+// be changed by their owner.
+func UpdateRoomOpenings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return
+	}
+	var body struct {
+		Openings []models.RoomOpening `json:"openings"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	var ownerID sql.NullInt64
+	var planJSON []byte
+	var width, depth, ceilingHeight float64
+	err = db.DB.QueryRow(`SELECT owner_id, floor_plan, width, depth, ceiling_height FROM room WHERE id = $1`, id).
+		Scan(&ownerID, &planJSON, &width, &depth, &ceilingHeight)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+	if !canEditRoom(c, ownerID) {
+		return
+	}
+
+	var plan *models.FloorPlan
+	outline := floorplan.RectangleOutline(width, depth)
+	wallHeight := ceilingHeight
+	if planJSON != nil {
+		plan = &models.FloorPlan{}
+		if err := json.Unmarshal(planJSON, plan); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid stored floor plan: " + err.Error()})
+			return
+		}
+		outline, wallHeight = plan.Floor, plan.WallHeight
+	}
+	if wallHeight <= 0 {
+		wallHeight = math.Inf(1) // catalog room without a measured ceiling
+	}
+	if err := floorplan.ValidateOpenings(outline, wallHeight, body.Openings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var objKey string
+	var refs []blobs.Ref
+	if plan != nil {
+		if objKey, refs, err = storeGeneratedRoom(ctx, *plan, body.Openings); err != nil {
+			log.Printf("Error storing generated room: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store room model"})
+			return
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM room_opening WHERE room_id = $1`, id)
+	if err == nil {
+		err = insertRoomOpenings(tx, id, body.Openings)
+	}
+	if err == nil && plan != nil {
+		// The rebuilt model replaces the old one with its GLB and thumbnail.
+		_, err = tx.Exec(`UPDATE room SET obj_file_path = $1, glb_file_path = '', thumbnail_path = '' WHERE id = $2`, objKey, id)
+		if err == nil {
+			err = blobs.Attach(ctx, tx, blobs.OwnerRoom, id, refs)
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update openings: " + err.Error()})
+		return
+	}
+
+	if plan != nil {
+		finishRoomModel(ctx, id, true)
+		scheduleRoomProjectPreviews(id)
+	}
+	respondRoom(c, http.StatusOK, id)
+}
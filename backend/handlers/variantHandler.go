@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"backend/blobs"
+	"backend/db"
+	"backend/mesh"
+	"backend/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// variantColumns is the column list read by scanVariant.
+const variantColumns = `id, furniture_id, name, material, color, finish,
+	texture_path, mtl_file_path, thumbnail_path, texture_files`
+
+// scanVariant reads a row selected with variantColumns.
+func scanVariant(row rowScanner) (models.FurnitureVariant, error) {
+	var v models.FurnitureVariant
+	err := row.Scan(&v.ID, &v.FurnitureID, &v.Name, &v.Material, &v.Color, &v.Finish,
+		&v.TexturePath, &v.MtlFilePath, &v.ThumbnailPath, pq.Array(&v.TextureFiles))
+	if err != nil {
+		return v, err
+	}
+	v.TexturePath = assetURL(v.TexturePath)
+	v.MtlFilePath = assetURL(v.MtlFilePath)
+	v.ThumbnailPath = assetURL(v.ThumbnailPath)
+	return v, nil
+}
+
+// attachVariants loads the variants of every item in one query.
+func attachVariants(items []models.Furniture) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(items))
+	byID := map[int]*models.Furniture{}
+	for i := range items {
+		ids[i] = int64(items[i].ID)
+		items[i].Variants = []models.FurnitureVariant{}
+		byID[items[i].ID] = &items[i]
+	}
+
+	rows, err := db.DB.Query("SELECT "+variantColumns+" FROM furniture_variant WHERE furniture_id = ANY($1) ORDER BY id",
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return err
+		}
+		if item, ok := byID[v.FurnitureID]; ok {
+			item.Variants = append(item.Variants, v)
+		}
+	}
+	return rows.Err()
+}
+
+// GetFurnitureVariants lists the variants of a catalog item.
+func GetFurnitureVariants(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+
+	rows, err := db.DB.Query("SELECT "+variantColumns+" FROM furniture_variant WHERE furniture_id = $1 ORDER BY id", id)
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	variants := []models.FurnitureVariant{}
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			log.Printf("Row scan error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
+			return
+		}
+		variants = append(variants, v)
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// objMaterialNames returns the materials used by the OBJ stored at dbPath.
+func objMaterialNames(ctx context.Context, dbPath string) ([]string, error) {
+	f, err := openAsset(ctx, dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	model, err := mesh.ParseOBJ(f)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, tri := range model.Triangles {
+		if tri.Material != "" && !seen[tri.Material] {
+			seen[tri.Material] = true
+			names = append(names, tri.Material)
+		}
+	}
+	return names, nil
+}
+
+// CreateFurnitureVariant adds a material set to a catalog item from a
+// multipart form with fields name, material, color, finish and optionally
+// texture, mtl, textures (repeatable) and thumbnail. An MTL must define every
+// material the item's OBJ uses so it can be swapped in without new geometry.
+func CreateFurnitureVariant(c *gin.Context) {
+	furnitureID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+	variant := models.FurnitureVariant{
+		FurnitureID: furnitureID,
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		Material:    strings.TrimSpace(c.PostForm("material")),
+		Color:       strings.TrimSpace(c.PostForm("color")),
+		Finish:      strings.TrimSpace(c.PostForm("finish")),
+	}
+	if variant.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Variant name is required"})
+		return
+	}
+
+	var objPath string
+	if err := db.DB.QueryRow("SELECT obj_file_path FROM furniture WHERE id = $1", furnitureID).Scan(&objPath); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+
+	textureFile, _ := c.FormFile("texture")
+	mtlFile, _ := c.FormFile("mtl")
+	thumbnailFile, _ := c.FormFile("thumbnail")
+	var textures []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		textures = form.File["textures"]
+	}
+	if textureFile != nil {
+		textures = append(textures, textureFile)
+	}
+	if textureFile == nil && mtlFile == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A texture or MTL file is required"})
+		return
+	}
+
+	materials := map[string]*mesh.Material{}
+	if mtlFile != nil {
+		mf, err := mtlFile.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read MTL file"})
+			return
+		}
+		materials, err = mesh.ParseMTL(mf)
+		mf.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid MTL file: " + err.Error()})
+			return
+		}
+
+		used, err := objMaterialNames(c.Request.Context(), objPath)
+		if err != nil {
+			log.Printf("Could not read model %s: %v", objPath, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not read the item's model"})
+			return
+		}
+		for _, name := range used {
+			if _, ok := materials[name]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("MTL does not define material %q used by the model", name)})
+				return
+			}
+		}
+
+		uploaded := map[string]bool{}
+		for _, t := range textures {
+			uploaded[t.Filename] = true
+		}
+		for _, tex := range mesh.TextureFiles(materials) {
+			if !uploaded[path.Base(tex)] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("MTL references texture %q which was not uploaded", tex)})
+				return
+			}
+		}
+	}
+
+	files := append([]*multipart.FileHeader{}, textures...)
+	if mtlFile != nil {
+		files = append(files, mtlFile)
+	}
+	if thumbnailFile != nil {
+		files = append(files, thumbnailFile)
+	}
+	stored, refs, err := storeUploadedBlobs(c.Request.Context(), files)
+	if err != nil {
+		log.Printf("Error storing uploaded files: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store files"})
+		return
+	}
+
+	variant.TextureFiles = mesh.TextureFiles(materials)
+	if textureFile != nil {
+		variant.TexturePath = stored[path.Base(textureFile.Filename)].Key
+	} else {
+		for _, mat := range materials {
+			if mat.DiffuseMap != "" {
+				variant.TexturePath = stored[path.Base(mat.DiffuseMap)].Key
+				break
+			}
+		}
+	}
+	if mtlFile != nil {
+		variant.MtlFilePath = stored[path.Base(mtlFile.Filename)].Key
+	}
+	if thumbnailFile != nil {
+		variant.ThumbnailPath = stored[path.Base(thumbnailFile.Filename)].Key
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		INSERT INTO furniture_variant (furniture_id, name, material, color, finish,
+		                               texture_path, mtl_file_path, thumbnail_path, texture_files)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+variantColumns,
+		variant.FurnitureID, variant.Name, variant.Material, variant.Color, variant.Finish,
+		variant.TexturePath, variant.MtlFilePath, variant.ThumbnailPath, pq.Array(variant.TextureFiles))
+	created, err := scanVariant(row)
+	if err == nil {
+		if err = blobs.Attach(c.Request.Context(), tx, blobs.OwnerVariant, created.ID, refs); err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		log.Printf("Database insert error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add variant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// DeleteFurnitureVariant removes a variant. Placements using it fall back to
+// the item's default finish.
+func DeleteFurnitureVariant(c *gin.Context) {
+	variantID, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM furniture_variant WHERE id = $1 AND furniture_id = $2", variantID, c.Param("id"))
+	if err != nil {
+		log.Printf("Database delete error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete variant: " + err.Error()})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
+
+// validateVariant checks that variantID, when set, belongs to furnitureID.
+func validateVariant(variantID *int, furnitureID int) error {
+	if variantID == nil {
+		return nil
+	}
+	var owner int
+	err := db.DB.QueryRow("SELECT furniture_id FROM furniture_variant WHERE id = $1", *variantID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("variant %d does not exist", *variantID)
+	}
+	if err != nil {
+		return err
+	}
+	if owner != furnitureID {
+		return fmt.Errorf("variant %d belongs to a different furniture item", *variantID)
+	}
+	return nil
+}
+
+// UpdatePlacedFurnitureVariant swaps the finish of a placed item in place.
+// A null variant_id restores the item's default finish.
+func UpdatePlacedFurnitureVariant(c *gin.Context) {
+	placedID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid furniture ID"})
+		return
+	}
+
+	var body struct {
+		VariantID *int `json:"variant_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+		return
+	}
+
+	var furnitureID int
+	err = db.DB.QueryRow(`SELECT furniture_id FROM "PlacedFurniture" WHERE id = $1`, placedID).Scan(&furnitureID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Furniture not found"})
+		} else {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		}
+		return
+	}
+	if err := validateVariant(body.VariantID, furnitureID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := db.DB.Exec(`UPDATE "PlacedFurniture" SET variant_id = $1 WHERE id = $2`, body.VariantID, placedID); err != nil {
+		log.Printf("Database update error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update variant: " + err.Error()})
+		return
+	}
+
+	updated, err := scanPlacedFurniture(db.DB.QueryRow(placedFurnitureSelect+`WHERE pf.id = $1`, placedID))
+	if err != nil {
+		log.Printf("Database query error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve updated furniture: " + err.Error()})
+		return
+	}
+	projectPreviews.schedule(updated.ProjectID)
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,426 @@
+// Package importer loads catalog items in bulk from a manifest and a ZIP of
+// model, texture and thumbnail files.
+package importer
+
+import (
+	"archive/zip"
+	"backend/blobs"
+	"backend/convert"
+	"backend/mesh"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// RowResult reports the outcome of one manifest row.
+type RowResult struct {
+	Row         int      `json:"row"`
+	SKU         string   `json:"sku"`
+	Status      string   `json:"status"` // created, updated, valid (dry run) or failed
+	FurnitureID int      `json:"furniture_id,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Report summarises an import run.
+type Report struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Failed  int         `json:"failed"`
+	Rows    []RowResult `json:"rows"`
+}
+
+// Importer upserts manifest rows into the furniture table by SKU. Files are
+// stored by content hash, so re-running an import reuses the same files and
+// rows instead of creating duplicates.
+type Importer struct {
+	DB     *sql.DB
+	Blobs  *blobs.Store
+	DryRun bool
+	// Converter, when set, converts every stored model to GLB, generates
+	// levels of detail for heavy ones and renders missing thumbnails.
+	Converter *convert.Converter
+}
+
+// archiveIndex looks files up in a ZIP by their slash separated path.
+type archiveIndex map[string]*zip.File
+
+func newArchiveIndex(archive *zip.Reader) archiveIndex {
+	index := archiveIndex{}
+	if archive == nil {
+		return index
+	}
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() {
+			index[cleanArchivePath(f.Name)] = f
+		}
+	}
+	return index
+}
+
+func cleanArchivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
+
+func (idx archiveIndex) lookup(name string) (*zip.File, bool) {
+	f, ok := idx[cleanArchivePath(name)]
+	return f, ok
+}
+
+// prepared is a validated row together with everything needed to store it.
+type prepared struct {
+	row        Row
+	meta       mesh.Metadata
+	files      map[string]*zip.File // destination path relative to the item dir -> source
+	objRel     string
+	mtlRel     string
+	textureRel string
+	thumbRel   string
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Run validates every row, then stores the valid ones unless DryRun is set.
+// Invalid rows are reported with their errors and do not stop the import.
+func (im *Importer) Run(ctx context.Context, rows []Row, archive *zip.Reader) (Report, error) {
+	report := Report{DryRun: im.DryRun, Total: len(rows), Rows: []RowResult{}}
+
+	categories, err := im.categories()
+	if err != nil {
+		return report, err
+	}
+	index := newArchiveIndex(archive)
+	seenSKU := map[string]int{}
+
+	for _, row := range rows {
+		result := RowResult{Row: row.Line, SKU: row.SKU}
+		item, errs := validate(row, index, categories)
+		if first, dup := seenSKU[row.SKU]; dup && row.SKU != "" {
+			errs = append(errs, fmt.Sprintf("duplicate SKU, first used on row %d", first))
+		} else {
+			seenSKU[row.SKU] = row.Line
+		}
+
+		switch {
+		case len(errs) > 0:
+			result.Status = "failed"
+			result.Errors = errs
+		case im.DryRun:
+			result.Status = "valid"
+		default:
+			id, created, err := im.store(ctx, item)
+			if err != nil {
+				result.Status = "failed"
+				result.Errors = []string{err.Error()}
+			} else {
+				result.Status = "updated"
+				if created {
+					result.Status = "created"
+				}
+				result.FurnitureID = id
+				if im.Converter != nil {
+					if _, err := im.Converter.Furniture(ctx, id); err != nil {
+						result.Warnings = append(result.Warnings, "GLB conversion failed: "+err.Error())
+					}
+					if item.meta.FaceCount >= convert.MinLODFaces {
+						if _, err := im.Converter.FurnitureLODs(ctx, id); err != nil {
+							result.Warnings = append(result.Warnings, "LOD generation failed: "+err.Error())
+						}
+					}
+					if item.thumbRel == "" {
+						if _, err := im.Converter.FurnitureThumbnail(ctx, id); err != nil {
+							result.Warnings = append(result.Warnings, "thumbnail rendering failed: "+err.Error())
+						}
+					}
+				}
+			}
+		}
+
+		switch result.Status {
+		case "failed":
+			report.Failed++
+		case "created":
+			report.Created++
+		case "updated":
+			report.Updated++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report, nil
+}
+
+func (im *Importer) categories() (map[string]bool, error) {
+	rows, err := im.DB.Query("SELECT slug FROM furniture_category")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	categories := map[string]bool{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		categories[slug] = true
+	}
+	return categories, rows.Err()
+}
+
+// validate checks a row and parses its model. It collects every problem
+// rather than stopping at the first one.
+func validate(row Row, index archiveIndex, categories map[string]bool) (*prepared, []string) {
+	errs := append([]string{}, row.parseErrors...)
+	row.SKU = strings.TrimSpace(row.SKU)
+	row.Name = strings.TrimSpace(row.Name)
+	row.Category = strings.ToLower(strings.TrimSpace(row.Category))
+	row.Currency = strings.ToUpper(strings.TrimSpace(row.Currency))
+
+	if row.SKU == "" {
+		errs = append(errs, "sku is required")
+	}
+	if row.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if row.Price < 0 {
+		errs = append(errs, "price cannot be negative")
+	}
+	if row.Width < 0 || row.Depth < 0 || row.Height < 0 {
+		errs = append(errs, "dimensions cannot be negative")
+	}
+	if row.Currency == "" {
+		row.Currency = "EUR"
+	} else if !currencyPattern.MatchString(row.Currency) {
+		errs = append(errs, fmt.Sprintf("invalid currency %q", row.Currency))
+	}
+	if row.Category != "" && !categories[row.Category] {
+		errs = append(errs, fmt.Sprintf("unknown category %q", row.Category))
+	}
+
+	item := &prepared{row: row, files: map[string]*zip.File{}}
+	if row.Obj == "" {
+		return item, append(errs, "obj is required")
+	}
+	objFile, ok := index.lookup(row.Obj)
+	if !ok {
+		return item, append(errs, fmt.Sprintf("obj file %q is not in the archive", row.Obj))
+	}
+	objDir := path.Dir(cleanArchivePath(row.Obj))
+	item.objRel = item.add(objDir, objFile)
+
+	model, err := parseZipped(objFile, mesh.ParseOBJ)
+	if err != nil {
+		return item, append(errs, fmt.Sprintf("invalid OBJ file: %v", err))
+	}
+
+	// The MTL comes from the manifest or, failing that, the OBJ's mtllib.
+	mtlName := row.Mtl
+	if mtlName == "" && len(model.MaterialLibs) > 0 {
+		mtlName = path.Join(objDir, model.MaterialLibs[0])
+	}
+	materials := map[string]*mesh.Material{}
+	if mtlName != "" {
+		mtlFile, ok := index.lookup(mtlName)
+		if !ok {
+			return item, append(errs, fmt.Sprintf("mtl file %q is not in the archive", mtlName))
+		}
+		item.mtlRel = item.add(objDir, mtlFile)
+		materials, err = parseZipped(mtlFile, mesh.ParseMTL)
+		if err != nil {
+			return item, append(errs, fmt.Sprintf("invalid MTL file: %v", err))
+		}
+		mtlDir := path.Dir(cleanArchivePath(mtlFile.Name))
+		for _, tex := range mesh.TextureFiles(materials) {
+			texFile, ok := index.lookup(path.Join(mtlDir, tex))
+			if !ok {
+				errs = append(errs, fmt.Sprintf("texture %q referenced by the MTL is not in the archive", tex))
+				continue
+			}
+			item.add(objDir, texFile)
+		}
+	}
+	for _, lib := range model.MaterialLibs {
+		if _, ok := index.lookup(path.Join(objDir, lib)); !ok && row.Mtl == "" {
+			errs = append(errs, fmt.Sprintf("material library %q referenced by the OBJ is not in the archive", lib))
+		}
+	}
+
+	if row.Texture != "" {
+		texFile, ok := index.lookup(row.Texture)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("texture file %q is not in the archive", row.Texture))
+		} else {
+			item.textureRel = item.add(objDir, texFile)
+		}
+	} else {
+		for _, mat := range materials {
+			if mat.DiffuseMap != "" {
+				item.textureRel = path.Clean(path.Join(path.Dir(item.mtlRel), mat.DiffuseMap))
+				break
+			}
+		}
+	}
+	if row.Thumbnail != "" {
+		thumbFile, ok := index.lookup(row.Thumbnail)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("thumbnail file %q is not in the archive", row.Thumbnail))
+		} else {
+			item.thumbRel = item.add(objDir, thumbFile)
+		}
+	}
+
+	item.meta = mesh.Inspect(model, materials)
+	return item, errs
+}
+
+// add registers an archive file for extraction and returns its destination
+// relative to the item directory. Files under the OBJ's directory keep their
+// relative layout so MTL and texture references still resolve; anything else
+// is stored by base name.
+func (p *prepared) add(objDir string, f *zip.File) string {
+	name := cleanArchivePath(f.Name)
+	rel := path.Base(name)
+	if objDir == "." {
+		rel = name
+	} else if strings.HasPrefix(name, objDir+"/") {
+		rel = strings.TrimPrefix(name, objDir+"/")
+	}
+	p.files[rel] = f
+	return rel
+}
+
+// maxArchiveFileSize bounds how much of a single archive file is read.
+const maxArchiveFileSize = 256 << 20
+
+// openZipped opens an archive file, refusing files larger than
+// maxArchiveFileSize and reading no more than the size the archive states.
+func openZipped(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > maxArchiveFileSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", f.Name, maxArchiveFileSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, int64(f.UncompressedSize64)), rc}, nil
+}
+
+func parseZipped[T any](f *zip.File, parse func(io.Reader) (T, error)) (T, error) {
+	rc, err := openZipped(f)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer rc.Close()
+	return parse(rc)
+}
+
+// store extracts the row's files and upserts it by SKU.
+func (im *Importer) store(ctx context.Context, item *prepared) (int, bool, error) {
+	row := item.row
+
+	names := make([]string, 0, len(item.files))
+	for rel := range item.files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+	stored := map[string]blobs.Blob{}
+	refs := make([]blobs.Ref, 0, len(names))
+	for _, rel := range names {
+		blob, err := im.extract(ctx, item.files[rel], rel)
+		if err != nil {
+			return 0, false, fmt.Errorf("could not store %s: %w", rel, err)
+		}
+		stored[rel] = blob
+		refs = append(refs, blobs.Ref{Name: rel, Hash: blob.Hash})
+	}
+
+	scale := mesh.UnitScale(item.meta.Units)
+	size := item.meta.Bounds.Size()
+	width, depth, height := size.X*scale, size.Z*scale, size.Y*scale
+	low := item.meta.Bounds.Min
+	origin := mesh.Vec3{X: low.X * scale, Y: low.Y * scale, Z: low.Z * scale}
+	source := "model"
+	if row.Width > 0 || row.Depth > 0 || row.Height > 0 {
+		source = "manual"
+	}
+	if row.Width > 0 {
+		width = row.Width
+	}
+	if row.Depth > 0 {
+		depth = row.Depth
+	}
+	if row.Height > 0 {
+		height = row.Height
+	}
+
+	keyOf := func(rel string) string {
+		return stored[rel].Key
+	}
+	tags := make([]string, 0, len(row.Tags))
+	for _, tag := range row.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	tx, err := im.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	var created bool
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO furniture (sku, name, obj_file_path, texture_path, thumbnail_path,
+		                       vertex_count, face_count, bounds_width, bounds_height, bounds_depth, texture_files, units,
+		                       width, depth, height, dimensions_source, category, tags, price, currency, supplier,
+		                       origin_x, origin_y, origin_z)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), $18, $19, $20, $21,
+		        $22, $23, $24)
+		ON CONFLICT (sku) DO UPDATE SET
+			name = EXCLUDED.name, obj_file_path = EXCLUDED.obj_file_path,
+			texture_path = EXCLUDED.texture_path, thumbnail_path = EXCLUDED.thumbnail_path,
+			vertex_count = EXCLUDED.vertex_count, face_count = EXCLUDED.face_count,
+			bounds_width = EXCLUDED.bounds_width, bounds_height = EXCLUDED.bounds_height,
+			bounds_depth = EXCLUDED.bounds_depth, texture_files = EXCLUDED.texture_files, units = EXCLUDED.units,
+			width = EXCLUDED.width, depth = EXCLUDED.depth, height = EXCLUDED.height,
+			dimensions_source = EXCLUDED.dimensions_source, category = EXCLUDED.category, tags = EXCLUDED.tags,
+			price = EXCLUDED.price, currency = EXCLUDED.currency, supplier = EXCLUDED.supplier,
+			origin_x = EXCLUDED.origin_x, origin_y = EXCLUDED.origin_y, origin_z = EXCLUDED.origin_z
+		RETURNING id, (xmax = 0)`,
+		row.SKU, row.Name, keyOf(item.objRel), keyOf(item.textureRel), keyOf(item.thumbRel),
+		item.meta.VertexCount, item.meta.FaceCount, size.X, size.Y, size.Z,
+		pq.Array(item.meta.Textures), item.meta.Units,
+		width, depth, height, source, row.Category, pq.Array(tags), row.Price, row.Currency, row.Supplier,
+		origin.X, origin.Y, origin.Z,
+	).Scan(&id, &created)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := blobs.Attach(ctx, tx, blobs.OwnerFurniture, id, refs); err != nil {
+		return 0, false, err
+	}
+	return id, created, tx.Commit()
+}
+
+func (im *Importer) extract(ctx context.Context, f *zip.File, name string) (blobs.Blob, error) {
+	rc, err := openZipped(f)
+	if err != nil {
+		return blobs.Blob{}, err
+	}
+	defer rc.Close()
+	return im.Blobs.Put(ctx, rc, name, "")
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Row is one catalog item described by an import manifest. File fields are
+// paths inside the accompanying ZIP archive.
+type Row struct {
+	Line      int      `json:"-"` // 1-based data row number in the manifest
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	Obj       string   `json:"obj"`
+	Mtl       string   `json:"mtl"`
+	Texture   string   `json:"texture"`
+	Thumbnail string   `json:"thumbnail"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	Price     float64  `json:"price"`
+	Currency  string   `json:"currency"`
+	Supplier  string   `json:"supplier"`
+	Width     float64  `json:"width"`
+	Depth     float64  `json:"depth"`
+	Height    float64  `json:"height"`
+
+	// parseErrors holds values that could not be read from a CSV manifest.
+	parseErrors []string
+}
+
+// ParseManifest reads a CSV or JSON manifest. format is "csv" or "json".
+// CSV manifests need a header row naming the Row fields; tags are separated
+// by ";" or ",". JSON manifests are an array of rows or {"items": [...]}.
+func ParseManifest(r io.Reader, format string) ([]Row, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseCSV(r)
+	case "json":
+		return parseJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported manifest format %q", format)
+}
+
+func parseJSON(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	if err := json.Unmarshal(data, &rows); err != nil {
+		var wrapped struct {
+			Items []Row `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil {
+			return nil, fmt.Errorf("invalid JSON manifest: %w", err)
+		}
+		rows = wrapped.Items
+	}
+	for i := range rows {
+		rows[i].Line = i + 1
+	}
+	return rows, nil
+}
+
+func parseCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"sku", "name", "obj"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV manifest is missing the %q column", required)
+		}
+	}
+
+	var rows []Row
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := Row{
+			Line:      line,
+			SKU:       get("sku"),
+			Name:      get("name"),
+			Obj:       get("obj"),
+			Mtl:       get("mtl"),
+			Texture:   get("texture"),
+			Thumbnail: get("thumbnail"),
+			Category:  get("category"),
+			Currency:  get("currency"),
+			Supplier:  get("supplier"),
+		}
+		if tags := get("tags"); tags != "" {
+			row.Tags = strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' })
+		}
+		// Bad numbers become row errors instead of failing the whole manifest.
+		for _, field := range []struct {
+			name   string
+			target *float64
+		}{{"price", &row.Price}, {"width", &row.Width}, {"depth", &row.Depth}, {"height", &row.Height}} {
+			raw := get(field.name)
+			if raw == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				row.parseErrors = append(row.parseErrors, fmt.Sprintf("%s %q is not a number", field.name, raw))
+				continue
+			}
+			*field.target = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"backend/blobs"
+	"backend/commands"
 	"backend/db"
 	"backend/routes"
+	"backend/storage"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -29,6 +33,23 @@ func main() {
 	// Initialize database
 	db.InitDB()
 
+	// Initialize asset storage
+	storage.Init()
+
+	// Maintenance subcommands run against the database and exit
+	if len(os.Args) > 1 {
+		if err := commands.Run(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Periodically remove asset files no catalog row references any more
+	if interval, err := time.ParseDuration(os.Getenv("ASSET_GC_INTERVAL")); err == nil && interval > 0 {
+		store := &blobs.Store{DB: db.DB, Files: storage.Assets}
+		go store.CollectEvery(context.Background(), interval, time.Hour)
+	}
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -42,8 +63,6 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	r.Static("/assets", "./assets/objects")
-
 	// Setup routes
 	routes.SetupRoutes(r)
 
@@ -0,0 +1,424 @@
+package mesh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Image is an encoded PNG or JPEG texture embedded into a GLB.
+type Image struct {
+	Data     []byte
+	MimeType string // image/png or image/jpeg
+}
+
+// GLBOptions controls how a Mesh is written as binary glTF.
+type GLBOptions struct {
+	// Materials are the MTL materials referenced by the mesh's triangles.
+	Materials map[string]*Material
+	// Textures holds the images of material diffuse maps, keyed by the
+	// DiffuseMap value of the material.
+	Textures map[string]Image
+	// Texture, when set, is applied to every material instead of its own
+	// diffuse map, matching how the editor textures whole models.
+	Texture *Image
+}
+
+// glTF JSON structures, limited to what WriteGLB produces.
+type gltfDoc struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name,omitempty"`
+	Mesh        int       `json:"mesh"`
+	Translation []float64 `json:"translation,omitempty"`
+	Rotation    []float64 `json:"rotation,omitempty"`
+	Scale       []float64 `json:"scale,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   *int           `json:"material,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string  `json:"name,omitempty"`
+	PBRMetallicRoughness gltfPBR `json:"pbrMetallicRoughness"`
+	DoubleSided          bool    `json:"doubleSided"`
+}
+
+type gltfPBR struct {
+	BaseColorFactor  [4]float64   `json:"baseColorFactor"`
+	BaseColorTexture *gltfTexInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float64      `json:"metallicFactor"`
+	RoughnessFactor  float64      `json:"roughnessFactor"`
+}
+
+type gltfTexInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source  int `json:"source"`
+	Sampler int `json:"sampler"`
+}
+
+type gltfImage struct {
+	BufferView int    `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// glTF enum values.
+const (
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+	gltfLinear       = 9729
+	gltfLinearMipmap = 9987
+	gltfRepeat       = 10497
+)
+
+// glbWriter accumulates the binary chunk and the JSON document.
+type glbWriter struct {
+	doc    gltfDoc
+	bin    bytes.Buffer
+	images map[[sha256.Size]byte]int // texture index by image content
+}
+
+// addView appends data to the binary chunk, 4-byte aligned, and returns the
+// index of its buffer view.
+func (g *glbWriter) addView(data []byte, target int) int {
+	for g.bin.Len()%4 != 0 {
+		g.bin.WriteByte(0)
+	}
+	g.doc.BufferViews = append(g.doc.BufferViews, gltfBufferView{
+		ByteOffset: g.bin.Len(), ByteLength: len(data), Target: target,
+	})
+	g.bin.Write(data)
+	return len(g.doc.BufferViews) - 1
+}
+
+func (g *glbWriter) addAccessor(view, componentType, count int, typ string, lo, hi []float64) int {
+	g.doc.Accessors = append(g.doc.Accessors, gltfAccessor{
+		BufferView: view, ComponentType: componentType, Count: count, Type: typ, Min: lo, Max: hi,
+	})
+	return len(g.doc.Accessors) - 1
+}
+
+// addImage embeds an image and returns its texture index. Identical images
+// are embedded once.
+func (g *glbWriter) addImage(img Image) int {
+	sum := sha256.Sum256(img.Data)
+	if idx, ok := g.images[sum]; ok {
+		return idx
+	}
+	view := g.addView(img.Data, 0)
+	g.doc.Images = append(g.doc.Images, gltfImage{BufferView: view, MimeType: img.MimeType})
+	if len(g.doc.Samplers) == 0 {
+		g.doc.Samplers = []gltfSampler{{MagFilter: gltfLinear, MinFilter: gltfLinearMipmap, WrapS: gltfRepeat, WrapT: gltfRepeat}}
+	}
+	g.doc.Textures = append(g.doc.Textures, gltfTexture{Source: len(g.doc.Images) - 1})
+	if g.images == nil {
+		g.images = map[[sha256.Size]byte]int{}
+	}
+	g.images[sum] = len(g.doc.Textures) - 1
+	return len(g.doc.Textures) - 1
+}
+
+func floatBytes(values []float32) []byte {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func uintBytes(values []uint32) []byte {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[4*i:], v)
+	}
+	return buf
+}
+
+// WriteGLB writes m as a self-contained binary glTF 2.0 file with one
+// primitive per material and every texture embedded. Coordinates are written
+// unchanged; like OBJ, glTF is right-handed with +Y up. Texture coordinates
+// are flipped to glTF's top-left origin.
+func WriteGLB(w io.Writer, m *Mesh, opts GLBOptions) error {
+	if len(m.Triangles) == 0 {
+		return ErrNoGeometry
+	}
+	g := newGLBWriter()
+	g.doc.Nodes = []gltfNode{{Mesh: g.addMesh("", m, opts)}}
+	g.doc.Scenes = []gltfScene{{Nodes: []int{0}}}
+	return g.write(w)
+}
+
+// SceneModel is a mesh with the options it is written with.
+type SceneModel struct {
+	Name    string
+	Mesh    *Mesh
+	Options GLBOptions
+}
+
+// SceneNode places a model in a scene.
+type SceneNode struct {
+	Name        string
+	Model       int // index into the scene's models
+	Translation Vec3
+	// Rotation is a unit quaternion (x, y, z, w); the zero value means none.
+	Rotation [4]float64
+	// Scale is applied along the model's own axes; the zero value means 1.
+	Scale Vec3
+}
+
+// WriteSceneGLB writes models placed by nodes as one binary glTF scene, as
+// WriteGLB writes a single model. Each model is stored once however many
+// nodes use it, and identical textures are embedded once.
+func WriteSceneGLB(w io.Writer, models []SceneModel, nodes []SceneNode) error {
+	if len(nodes) == 0 {
+		return ErrNoGeometry
+	}
+	g := newGLBWriter()
+	meshIndex := map[int]int{}
+	scene := gltfScene{Nodes: []int{}}
+	for _, node := range nodes {
+		if node.Model < 0 || node.Model >= len(models) {
+			return fmt.Errorf("node %q uses model %d of %d", node.Name, node.Model, len(models))
+		}
+		idx, ok := meshIndex[node.Model]
+		if !ok {
+			model := models[node.Model]
+			if len(model.Mesh.Triangles) == 0 {
+				return fmt.Errorf("model %q: %w", model.Name, ErrNoGeometry)
+			}
+			idx = g.addMesh(model.Name, model.Mesh, model.Options)
+			meshIndex[node.Model] = idx
+		}
+		n := gltfNode{Name: node.Name, Mesh: idx}
+		if t := node.Translation; t != (Vec3{}) {
+			n.Translation = []float64{t.X, t.Y, t.Z}
+		}
+		if r := node.Rotation; r != [4]float64{} && r != [4]float64{0, 0, 0, 1} {
+			n.Rotation = r[:]
+		}
+		if s := node.Scale; s != (Vec3{}) && s != (Vec3{X: 1, Y: 1, Z: 1}) {
+			n.Scale = []float64{s.X, s.Y, s.Z}
+		}
+		scene.Nodes = append(scene.Nodes, len(g.doc.Nodes))
+		g.doc.Nodes = append(g.doc.Nodes, n)
+	}
+	g.doc.Scenes = []gltfScene{scene}
+	return g.write(w)
+}
+
+func newGLBWriter() *glbWriter {
+	g := &glbWriter{}
+	g.doc.Asset = gltfAsset{Version: "2.0", Generator: "room-design backend"}
+	return g
+}
+
+// addMesh adds m with its materials and textures and returns its mesh index.
+func (g *glbWriter) addMesh(name string, m *Mesh, opts GLBOptions) int {
+	// Group triangles by material, in a stable order.
+	groups := map[string][]Triangle{}
+	var names []string
+	for _, tri := range m.Triangles {
+		if _, ok := groups[tri.Material]; !ok {
+			names = append(names, tri.Material)
+		}
+		groups[tri.Material] = append(groups[tri.Material], tri)
+	}
+	sort.Strings(names)
+
+	overrideTexture := -1
+	if opts.Texture != nil {
+		overrideTexture = g.addImage(*opts.Texture)
+	}
+
+	var primitives []gltfPrimitive
+	for _, matName := range names {
+		prim := g.addPrimitive(m, groups[matName])
+
+		mat := gltfMaterial{Name: matName, DoubleSided: true, PBRMetallicRoughness: gltfPBR{
+			BaseColorFactor: [4]float64{1, 1, 1, 1}, RoughnessFactor: 1,
+		}}
+		if src, ok := opts.Materials[matName]; ok && overrideTexture < 0 {
+			mat.PBRMetallicRoughness.BaseColorFactor = [4]float64{src.Diffuse[0], src.Diffuse[1], src.Diffuse[2], 1}
+			if img, ok := opts.Textures[src.DiffuseMap]; ok && src.DiffuseMap != "" {
+				mat.PBRMetallicRoughness.BaseColorTexture = &gltfTexInfo{Index: g.addImage(img)}
+			}
+		}
+		if overrideTexture >= 0 {
+			mat.PBRMetallicRoughness.BaseColorTexture = &gltfTexInfo{Index: overrideTexture}
+		}
+		g.doc.Materials = append(g.doc.Materials, mat)
+		materialIndex := len(g.doc.Materials) - 1
+		prim.Material = &materialIndex
+		primitives = append(primitives, prim)
+	}
+
+	g.doc.Meshes = append(g.doc.Meshes, gltfMesh{Name: name, Primitives: primitives})
+	return len(g.doc.Meshes) - 1
+}
+
+// addPrimitive de-indexes the OBJ corners of tris into glTF vertices. Normals
+// and texture coordinates are only written when every corner has them.
+func (g *glbWriter) addPrimitive(m *Mesh, tris []Triangle) gltfPrimitive {
+	hasUV, hasNormal := true, true
+	for _, tri := range tris {
+		for _, v := range tri.V {
+			hasUV = hasUV && v.T >= 0
+			hasNormal = hasNormal && v.N >= 0
+		}
+	}
+
+	vertexOf := map[Index]uint32{}
+	var positions, normals, uvs []float32
+	indices := make([]uint32, 0, 3*len(tris))
+	lo := []float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := []float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for _, tri := range tris {
+		for _, corner := range tri.V {
+			key := corner
+			if !hasUV {
+				key.T = -1
+			}
+			if !hasNormal {
+				key.N = -1
+			}
+			idx, ok := vertexOf[key]
+			if !ok {
+				idx = uint32(len(vertexOf))
+				vertexOf[key] = idx
+				p := m.Positions[key.P]
+				positions = append(positions, float32(p.X), float32(p.Y), float32(p.Z))
+				for axis, value := range []float64{float64(float32(p.X)), float64(float32(p.Y)), float64(float32(p.Z))} {
+					lo[axis] = math.Min(lo[axis], value)
+					hi[axis] = math.Max(hi[axis], value)
+				}
+				if hasNormal {
+					n := m.Normals[key.N]
+					normals = append(normals, float32(n.X), float32(n.Y), float32(n.Z))
+				}
+				if hasUV {
+					t := m.UVs[key.T]
+					uvs = append(uvs, float32(t.U), float32(1-t.V))
+				}
+			}
+			indices = append(indices, idx)
+		}
+	}
+
+	count := len(vertexOf)
+	prim := gltfPrimitive{Attributes: map[string]int{}}
+	prim.Attributes["POSITION"] = g.addAccessor(g.addView(floatBytes(positions), gltfArrayBuffer), gltfFloat, count, "VEC3", lo, hi)
+	if hasNormal {
+		prim.Attributes["NORMAL"] = g.addAccessor(g.addView(floatBytes(normals), gltfArrayBuffer), gltfFloat, count, "VEC3", nil, nil)
+	}
+	if hasUV {
+		prim.Attributes["TEXCOORD_0"] = g.addAccessor(g.addView(floatBytes(uvs), gltfArrayBuffer), gltfFloat, count, "VEC2", nil, nil)
+	}
+	prim.Indices = g.addAccessor(g.addView(uintBytes(indices), gltfElementArray), gltfUnsignedInt, len(indices), "SCALAR", nil, nil)
+	return prim
+}
+
+// write emits the GLB container: a 12-byte header, the JSON chunk padded with
+// spaces and the binary chunk padded with zeros.
+func (g *glbWriter) write(w io.Writer) error {
+	for g.bin.Len()%4 != 0 {
+		g.bin.WriteByte(0)
+	}
+	g.doc.Buffers = []gltfBuffer{{ByteLength: g.bin.Len()}}
+	jsonChunk, err := json.Marshal(g.doc)
+	if err != nil {
+		return err
+	}
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+	binChunk := g.bin.Bytes()
+
+	total := 12 + 8 + len(jsonChunk) + 8 + len(binChunk)
+	header := make([]byte, 0, 20)
+	header = binary.LittleEndian.AppendUint32(header, 0x46546C67) // "glTF"
+	header = binary.LittleEndian.AppendUint32(header, 2)
+	header = binary.LittleEndian.AppendUint32(header, uint32(total))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(jsonChunk)))
+	header = binary.LittleEndian.AppendUint32(header, 0x4E4F534A) // "JSON"
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(jsonChunk); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, 8)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(binChunk)))
+	chunk = binary.LittleEndian.AppendUint32(chunk, 0x004E4942) // "BIN\0"
+	if _, err := w.Write(chunk); err != nil {
+		return err
+	}
+	_, err = w.Write(binChunk)
+	return err
+}
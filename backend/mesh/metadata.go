@@ -0,0 +1,113 @@
+package mesh
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Box is an axis-aligned bounding box.
+type Box struct {
+	Min, Max Vec3
+}
+
+// Size returns the extent of the box along each axis.
+func (b Box) Size() Vec3 {
+	return Vec3{b.Max.X - b.Min.X, b.Max.Y - b.Min.Y, b.Max.Z - b.Min.Z}
+}
+
+// Bounds returns the bounding box of every vertex referenced by a face.
+func (m *Mesh) Bounds() Box {
+	box := Box{
+		Min: Vec3{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: Vec3{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+	for _, tri := range m.Triangles {
+		for _, idx := range tri.V {
+			p := m.Positions[idx.P]
+			box.Min = Vec3{math.Min(box.Min.X, p.X), math.Min(box.Min.Y, p.Y), math.Min(box.Min.Z, p.Z)}
+			box.Max = Vec3{math.Max(box.Max.X, p.X), math.Max(box.Max.Y, p.Y), math.Max(box.Max.Z, p.Z)}
+		}
+	}
+	if len(m.Triangles) == 0 {
+		return Box{}
+	}
+	return box
+}
+
+// Metadata summarises a parsed model for the catalog.
+type Metadata struct {
+	VertexCount int
+	FaceCount   int
+	Bounds      Box
+	Textures    []string
+	Units       string
+}
+
+// Inspect collects catalog metadata for a mesh and its materials. Units fall
+// back to a guess from the model extent when the file does not declare them.
+func Inspect(m *Mesh, materials map[string]*Material) Metadata {
+	md := Metadata{
+		VertexCount: len(m.Positions),
+		FaceCount:   len(m.Triangles),
+		Bounds:      m.Bounds(),
+		Textures:    TextureFiles(materials),
+		Units:       m.Units,
+	}
+	sort.Strings(md.Textures)
+	if md.Units == "" {
+		md.Units = GuessUnits(md.Bounds)
+	}
+	return md
+}
+
+// GuessUnits picks the most plausible unit for furniture-sized models.
+// Anything longer than 50 units is unlikely to be metres, and anything
+// longer than 500 unlikely to be centimetres.
+func GuessUnits(b Box) string {
+	size := b.Size()
+	longest := math.Max(size.X, math.Max(size.Y, size.Z))
+	switch {
+	case longest > 500:
+		return "mm"
+	case longest > 50:
+		return "cm"
+	default:
+		return "m"
+	}
+}
+
+// NormalizeUnits maps unit spellings onto the short forms stored in the DB.
+func NormalizeUnits(units string) string {
+	switch strings.ToLower(strings.TrimSpace(units)) {
+	case "m", "meter", "meters", "metre", "metres":
+		return "m"
+	case "cm", "centimeter", "centimeters", "centimetre", "centimetres":
+		return "cm"
+	case "mm", "millimeter", "millimeters", "millimetre", "millimetres":
+		return "mm"
+	case "in", "inch", "inches":
+		return "in"
+	case "ft", "foot", "feet":
+		return "ft"
+	}
+	return ""
+}
+
+// UnitScale returns the factor converting the unit to metres, or 0 when the
+// unit is unknown.
+func UnitScale(units string) float64 {
+	switch NormalizeUnits(units) {
+	case "m":
+		return 1
+	case "cm":
+		return 0.01
+	case "mm":
+		return 0.001
+	case "in":
+		return 0.0254
+	case "ft":
+		return 0.3048
+	}
+	return 0
+}
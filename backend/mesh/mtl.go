@@ -0,0 +1,98 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Material is the subset of an MTL material the editor cares about.
+type Material struct {
+	Name       string
+	Diffuse    [3]float64
+	DiffuseMap string
+	// Maps holds every texture referenced by the material, keyed by statement
+	// (map_Kd, map_Bump, ...).
+	Maps map[string]string
+}
+
+// textureStatements are the MTL statements that reference an image file.
+var textureStatements = map[string]bool{
+	"map_ka": true, "map_kd": true, "map_ks": true, "map_ke": true, "map_ns": true,
+	"map_d": true, "map_bump": true, "bump": true, "disp": true, "decal": true,
+	"norm": true, "map_pr": true, "map_pm": true, "refl": true,
+}
+
+// ParseMTL reads a Wavefront material library.
+func ParseMTL(r io.Reader) (map[string]*Material, error) {
+	materials := map[string]*Material{}
+	var current *Material
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		keyword := strings.ToLower(fields[0])
+
+		if keyword == "newmtl" {
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: newmtl without a name", lineNo)
+			}
+			current = &Material{Name: fields[1], Diffuse: [3]float64{1, 1, 1}, Maps: map[string]string{}}
+			materials[current.Name] = current
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %s before any newmtl", lineNo, fields[0])
+		}
+
+		switch {
+		case keyword == "kd":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: Kd needs 3 components", lineNo)
+			}
+			for i := 0; i < 3; i++ {
+				f, err := parseNumber(fields[i+1])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: Kd: %w", lineNo, err)
+				}
+				current.Diffuse[i] = f
+			}
+		case textureStatements[keyword]:
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: %s without a file name", lineNo, fields[0])
+			}
+			// Options such as "-s 1 1 1" precede the file name, which is last.
+			file := strings.ReplaceAll(fields[len(fields)-1], "\\", "/")
+			current.Maps[fields[0]] = file
+			if keyword == "map_kd" {
+				current.DiffuseMap = file
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+// TextureFiles lists the distinct texture files referenced by the materials.
+func TextureFiles(materials map[string]*Material) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, mat := range materials {
+		for _, file := range mat.Maps {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,215 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNoGeometry is returned when an OBJ file parses but contains no faces.
+var ErrNoGeometry = errors.New("obj file contains no faces")
+
+// Vec2 is a texture coordinate.
+type Vec2 struct {
+	U, V float64
+}
+
+// Vec3 is a position or direction in model space.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// Index points into the position, texture and normal arrays of a Mesh.
+// T and N are -1 when the face corner has no texture coordinate or normal.
+type Index struct {
+	P, T, N int
+}
+
+// Triangle is a single triangulated face and the material it was drawn with.
+type Triangle struct {
+	V        [3]Index
+	Material string
+}
+
+// Mesh is the geometry read from an OBJ file. Polygons are fan-triangulated
+// while parsing so every consumer works with triangles only.
+type Mesh struct {
+	Positions    []Vec3
+	UVs          []Vec2
+	Normals      []Vec3
+	Triangles    []Triangle
+	MaterialLibs []string
+	// Units is the unit declared in a "# units: <unit>" comment, if any.
+	Units string
+}
+
+// ParseOBJ reads a Wavefront OBJ file. It returns an error pointing at the
+// offending line for malformed statements and out of range face indices.
+func ParseOBJ(r io.Reader) (*Mesh, error) {
+	m := &Mesh{}
+	material := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if units, ok := parseUnitsComment(line); ok {
+				m.Units = units
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "v":
+			v, err := parseVec3(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: vertex: %w", lineNo, err)
+			}
+			m.Positions = append(m.Positions, v)
+		case "vt":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: texture coordinate: missing values", lineNo)
+			}
+			u, err := parseNumber(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: texture coordinate: %w", lineNo, err)
+			}
+			var v float64
+			if len(fields) > 2 {
+				if v, err = parseNumber(fields[2]); err != nil {
+					return nil, fmt.Errorf("line %d: texture coordinate: %w", lineNo, err)
+				}
+			}
+			m.UVs = append(m.UVs, Vec2{u, v})
+		case "vn":
+			n, err := parseVec3(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: normal: %w", lineNo, err)
+			}
+			m.Normals = append(m.Normals, n)
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: face needs at least 3 vertices", lineNo)
+			}
+			corners := make([]Index, 0, len(fields)-1)
+			for _, ref := range fields[1:] {
+				idx, err := m.parseIndex(ref)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: face: %w", lineNo, err)
+				}
+				corners = append(corners, idx)
+			}
+			for i := 1; i+1 < len(corners); i++ {
+				m.Triangles = append(m.Triangles, Triangle{
+					V:        [3]Index{corners[0], corners[i], corners[i+1]},
+					Material: material,
+				})
+			}
+		case "mtllib":
+			m.MaterialLibs = append(m.MaterialLibs, fields[1:]...)
+		case "usemtl":
+			if len(fields) > 1 {
+				material = fields[1]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(m.Triangles) == 0 {
+		return nil, ErrNoGeometry
+	}
+	return m, nil
+}
+
+func parseVec3(fields []string) (Vec3, error) {
+	if len(fields) < 3 {
+		return Vec3{}, errors.New("expected 3 components")
+	}
+	var out [3]float64
+	for i := 0; i < 3; i++ {
+		f, err := parseNumber(fields[i])
+		if err != nil {
+			return Vec3{}, err
+		}
+		out[i] = f
+	}
+	return Vec3{out[0], out[1], out[2]}, nil
+}
+
+// parseNumber reads a finite number. NaN and infinities would poison the
+// bounds and everything measured from them.
+func parseNumber(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return f, nil
+}
+
+// parseIndex resolves a "p/t/n" face reference into zero based indices.
+// Negative OBJ indices are relative to the end of the current arrays.
+func (m *Mesh) parseIndex(ref string) (Index, error) {
+	parts := strings.Split(ref, "/")
+	idx := Index{P: -1, T: -1, N: -1}
+
+	resolve := func(s string, count int, what string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return -1, fmt.Errorf("bad %s index %q", what, s)
+		}
+		if n < 0 {
+			n = count + n
+		} else {
+			n--
+		}
+		if n < 0 || n >= count {
+			return -1, fmt.Errorf("%s index %s out of range (have %d)", what, s, count)
+		}
+		return n, nil
+	}
+
+	var err error
+	if idx.P, err = resolve(parts[0], len(m.Positions), "vertex"); err != nil {
+		return idx, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if idx.T, err = resolve(parts[1], len(m.UVs), "texture"); err != nil {
+			return idx, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if idx.N, err = resolve(parts[2], len(m.Normals), "normal"); err != nil {
+			return idx, err
+		}
+	}
+	return idx, nil
+}
+
+// parseUnitsComment recognises exporter comments such as "# Units: cm".
+func parseUnitsComment(line string) (string, bool) {
+	body := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	for _, prefix := range []string{"units:", "units =", "unit:", "units"} {
+		if strings.HasPrefix(body, prefix) {
+			fields := strings.Fields(strings.TrimPrefix(body, prefix))
+			if len(fields) > 0 && UnitScale(fields[0]) != 0 {
+				return NormalizeUnits(fields[0]), true
+			}
+		}
+	}
+	return "", false
+}
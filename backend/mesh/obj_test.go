@@ -0,0 +1,36 @@
+package mesh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOBJNumbers(t *testing.T) {
+	const face = "v 0 0 0\nv 1 0 0\nv 0 1 0\n"
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"plain", face + "vt 0.5 1\nvn 0 0 1\nf 1 2 3", ""},
+		{"nan vertex", "v nan 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3", `line 1: vertex: invalid number "nan"`},
+		{"infinite vertex", "v 0 0 0\nv 1 Inf 0\nv 0 1 0\nf 1 2 3", `line 2: vertex: invalid number "Inf"`},
+		{"out of range vertex", "v 0 0 0\nv 1e999 0 0\nv 0 1 0\nf 1 2 3", "line 2: vertex"},
+		{"nan texture coordinate", face + "vt 0 nan\nf 1 2 3", `line 4: texture coordinate: invalid number "nan"`},
+		{"infinite normal", face + "vn -inf 0 1\nf 1 2 3", `line 4: normal: invalid number "-inf"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOBJ(strings.NewReader(tt.input))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// WriteOBJ writes m as a Wavefront OBJ file, with a units comment that
+// ParseOBJ picks up when m.Units is set.
+func WriteOBJ(w io.Writer, m *Mesh) error {
+	bw := bufio.NewWriter(w)
+	if m.Units != "" {
+		fmt.Fprintf(bw, "# units: %s\n", m.Units)
+	}
+	for _, lib := range m.MaterialLibs {
+		fmt.Fprintf(bw, "mtllib %s\n", lib)
+	}
+	for _, p := range m.Positions {
+		fmt.Fprintf(bw, "v %s %s %s\n", formatFloat(p.X), formatFloat(p.Y), formatFloat(p.Z))
+	}
+	for _, t := range m.UVs {
+		fmt.Fprintf(bw, "vt %s %s\n", formatFloat(t.U), formatFloat(t.V))
+	}
+	for _, n := range m.Normals {
+		fmt.Fprintf(bw, "vn %s %s %s\n", formatFloat(n.X), formatFloat(n.Y), formatFloat(n.Z))
+	}
+	material := ""
+	for i, tri := range m.Triangles {
+		if i == 0 || tri.Material != material {
+			material = tri.Material
+			if material != "" {
+				fmt.Fprintf(bw, "usemtl %s\n", material)
+			}
+		}
+		bw.WriteString("f")
+		for _, v := range tri.V {
+			bw.WriteString(" " + strconv.Itoa(v.P+1))
+			switch {
+			case v.T >= 0 && v.N >= 0:
+				fmt.Fprintf(bw, "/%d/%d", v.T+1, v.N+1)
+			case v.T >= 0:
+				fmt.Fprintf(bw, "/%d", v.T+1)
+			case v.N >= 0:
+				fmt.Fprintf(bw, "//%d", v.N+1)
+			}
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// WriteMTL writes a material library with the diffuse colour and map of each
+// material, in name order.
+func WriteMTL(w io.Writer, materials map[string]*Material) error {
+	names := make([]string, 0, len(materials))
+	for name := range materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for i, name := range names {
+		mat := materials[name]
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "newmtl %s\n", name)
+		fmt.Fprintf(bw, "Kd %s %s %s\n", formatFloat(mat.Diffuse[0]), formatFloat(mat.Diffuse[1]), formatFloat(mat.Diffuse[2]))
+		if mat.DiffuseMap != "" {
+			fmt.Fprintf(bw, "map_Kd %s\n", mat.DiffuseMap)
+		}
+	}
+	return bw.Flush()
+}
+
+// formatFloat rounds to micrometres, far below modelling precision, to keep
+// files short.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
@@ -0,0 +1,119 @@
+package mesh
+
+import "math"
+
+// Simplify reduces m to at most targetFaces triangles by vertex clustering:
+// positions are snapped to a uniform grid, merged per cell, and triangles
+// that collapse are dropped. The grid is the finest one that meets the
+// target. Texture coordinates and normals of the surviving corners are kept,
+// so materials still map. m itself is not modified; it is returned unchanged
+// when it is already small enough.
+func Simplify(m *Mesh, targetFaces int) *Mesh {
+	if len(m.Triangles) <= targetFaces {
+		return m
+	}
+	bounds := m.Bounds()
+	size := bounds.Size()
+	longest := math.Max(size.X, math.Max(size.Y, size.Z))
+	if longest <= 0 {
+		return m
+	}
+
+	var best *Mesh
+	lo, hi := 1, 4096
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		candidate := cluster(m, bounds, longest/float64(mid))
+		if len(candidate.Triangles) <= targetFaces {
+			best = candidate
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if best == nil {
+		return cluster(m, bounds, longest)
+	}
+	return best
+}
+
+// cluster merges the positions of m that fall into the same grid cell.
+func cluster(m *Mesh, bounds Box, cell float64) *Mesh {
+	type cellKey [3]int
+	type faceKey struct {
+		a, b, c  int
+		material string
+	}
+
+	cellOf := func(p Vec3) cellKey {
+		return cellKey{
+			int(math.Floor((p.X - bounds.Min.X) / cell)),
+			int(math.Floor((p.Y - bounds.Min.Y) / cell)),
+			int(math.Floor((p.Z - bounds.Min.Z) / cell)),
+		}
+	}
+
+	clusters := map[cellKey]int{}
+	var sums []Vec3
+	var counts []int
+	remap := make([]int, len(m.Positions))
+	used := make([]bool, len(m.Positions))
+	for _, tri := range m.Triangles {
+		for _, v := range tri.V {
+			used[v.P] = true
+		}
+	}
+	for i, p := range m.Positions {
+		if !used[i] {
+			continue
+		}
+		key := cellOf(p)
+		idx, ok := clusters[key]
+		if !ok {
+			idx = len(sums)
+			clusters[key] = idx
+			sums = append(sums, Vec3{})
+			counts = append(counts, 0)
+		}
+		remap[i] = idx
+		sums[idx].X += p.X
+		sums[idx].Y += p.Y
+		sums[idx].Z += p.Z
+		counts[idx]++
+	}
+
+	out := &Mesh{
+		Positions:    make([]Vec3, len(sums)),
+		UVs:          m.UVs,
+		Normals:      m.Normals,
+		MaterialLibs: m.MaterialLibs,
+		Units:        m.Units,
+	}
+	for i, sum := range sums {
+		n := float64(counts[i])
+		out.Positions[i] = Vec3{sum.X / n, sum.Y / n, sum.Z / n}
+	}
+
+	seen := map[faceKey]bool{}
+	for _, tri := range m.Triangles {
+		a, b, c := remap[tri.V[0].P], remap[tri.V[1].P], remap[tri.V[2].P]
+		if a == b || b == c || a == c {
+			continue
+		}
+		// The same face may survive from several source triangles; keep one,
+		// regardless of winding start.
+		key := faceKey{a, b, c, tri.Material}
+		for key.a > key.b || key.a > key.c {
+			key.a, key.b, key.c = key.b, key.c, key.a
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		simplified := tri
+		simplified.V[0].P, simplified.V[1].P, simplified.V[2].P = a, b, c
+		out.Triangles = append(out.Triangles, simplified)
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package mesh
+
+import "math"
+
+// Euler is an orientation as turns in radians about the X, Y and Z axes,
+// applied in XYZ order as three.js does: a point is turned about Z first,
+// then Y, then X, all about the fixed axes.
+type Euler struct {
+	X, Y, Z float64
+}
+
+// Matrix returns the rotation matrix of e, row by row.
+func (e Euler) Matrix() [3][3]float64 {
+	sx, cx := math.Sincos(e.X)
+	sy, cy := math.Sincos(e.Y)
+	sz, cz := math.Sincos(e.Z)
+	return [3][3]float64{
+		{cy * cz, -cy * sz, sy},
+		{cx*sz + sx*sy*cz, cx*cz - sx*sy*sz, -sx * cy},
+		{sx*sz - cx*sy*cz, sx*cz + cx*sy*sz, cx * cy},
+	}
+}
+
+// Quaternion returns e as a unit quaternion (x, y, z, w).
+func (e Euler) Quaternion() [4]float64 {
+	s1, c1 := math.Sincos(e.X / 2)
+	s2, c2 := math.Sincos(e.Y / 2)
+	s3, c3 := math.Sincos(e.Z / 2)
+	return [4]float64{
+		s1*c2*c3 + c1*s2*s3,
+		c1*s2*c3 - s1*c2*s3,
+		c1*c2*s3 + s1*s2*c3,
+		c1*c2*c3 - s1*s2*s3,
+	}
+}
+
+// EulerFromQuaternion converts a quaternion (x, y, z, w) to XYZ Euler
+// angles. q is normalized first; the zero quaternion is no rotation. Every
+// orientation has two sets of angles; the one with the smaller X and Z turns
+// is returned, so a turn about the vertical axis alone only has a Y angle,
+// which is all the editor reads.
+func EulerFromQuaternion(q [4]float64) Euler {
+	n := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	if n == 0 {
+		return Euler{}
+	}
+	x, y, z, w := q[0]/n, q[1]/n, q[2]/n, q[3]/n
+	if math.Abs(x) < 1e-12 && math.Abs(z) < 1e-12 {
+		return Euler{Y: math.Remainder(2*math.Atan2(y, w), 2*math.Pi)}
+	}
+	m11 := 1 - 2*(y*y+z*z)
+	m12 := 2 * (x*y - z*w)
+	m13 := 2 * (x*z + y*w)
+	m22 := 1 - 2*(x*x+z*z)
+	m23 := 2 * (y*z - x*w)
+	m32 := 2 * (y*z + x*w)
+	m33 := 1 - 2*(x*x+y*y)
+
+	e := Euler{Y: math.Asin(math.Max(-1, math.Min(1, m13)))}
+	if math.Abs(m13) < 0.9999999 {
+		e.X = math.Atan2(-m23, m33)
+		e.Z = math.Atan2(-m12, m11)
+	} else {
+		// Gimbal lock: X and Z turn about the same axis, so put it all on X.
+		e.X = math.Atan2(m32, m22)
+	}
+	// (X+π, π-Y, Z+π) turns the same way.
+	alt := Euler{
+		X: math.Remainder(e.X+math.Pi, 2*math.Pi),
+		Y: math.Remainder(math.Pi-e.Y, 2*math.Pi),
+		Z: math.Remainder(e.Z+math.Pi, 2*math.Pi),
+	}
+	if math.Abs(alt.X)+math.Abs(alt.Z) < math.Abs(e.X)+math.Abs(e.Z)-1e-9 {
+		return alt
+	}
+	return e
+}
+
+// Transform places a model as the editor does: scaled along its own axes,
+// turned, then moved to Position.
+type Transform struct {
+	Position Vec3
+	Rotation Euler
+	Scale    Vec3
+}
+
+// Identity leaves a model where it is.
+var Identity = Transform{Scale: Vec3{X: 1, Y: 1, Z: 1}}
+
+// Point returns where a point of the model ends up.
+func (t Transform) Point(v Vec3) Vec3 {
+	m := t.Rotation.Matrix()
+	s := Vec3{X: v.X * t.Scale.X, Y: v.Y * t.Scale.Y, Z: v.Z * t.Scale.Z}
+	return Vec3{
+		X: m[0][0]*s.X + m[0][1]*s.Y + m[0][2]*s.Z + t.Position.X,
+		Y: m[1][0]*s.X + m[1][1]*s.Y + m[1][2]*s.Z + t.Position.Y,
+		Z: m[2][0]*s.X + m[2][1]*s.Y + m[2][2]*s.Z + t.Position.Z,
+	}
+}
+
+// Direction returns where a direction of the model points afterwards,
+// turned but not scaled.
+func (t Transform) Direction(d Vec3) Vec3 {
+	m := t.Rotation.Matrix()
+	return Vec3{
+		X: m[0][0]*d.X + m[0][1]*d.Y + m[0][2]*d.Z,
+		Y: m[1][0]*d.X + m[1][1]*d.Y + m[1][2]*d.Z,
+		Z: m[2][0]*d.X + m[2][1]*d.Y + m[2][2]*d.Z,
+	}
+}
+
+// Normal returns a surface normal of the model after the transform, unit
+// length. Scaling a surface tilts its normal the opposite way, so the normal
+// is divided by the scale before turning.
+func (t Transform) Normal(n Vec3) Vec3 {
+	d := t.Direction(Vec3{X: n.X / t.Scale.X, Y: n.Y / t.Scale.Y, Z: n.Z / t.Scale.Z})
+	l := math.Sqrt(d.X*d.X + d.Y*d.Y + d.Z*d.Z)
+	if l == 0 {
+		return d
+	}
+	return Vec3{X: d.X / l, Y: d.Y / l, Z: d.Z / l}
+}
+
+// TurnY returns e turned a further angle radians about the vertical axis.
+// Untilted orientations only change their Y angle.
+func (e Euler) TurnY(angle float64) Euler {
+	if e.X == 0 && e.Z == 0 {
+		return Euler{Y: e.Y + angle}
+	}
+	s, c := math.Sincos(angle / 2)
+	q := e.Quaternion()
+	return EulerFromQuaternion([4]float64{
+		c*q[0] + s*q[2],
+		c*q[1] + s*q[3],
+		c*q[2] - s*q[0],
+		c*q[3] - s*q[1],
+	})
+}
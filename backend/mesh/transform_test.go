@@ -0,0 +1,114 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// sameTurn reports whether two orientations turn every point the same way.
+func sameTurn(a, b Euler) bool {
+	ma, mb := a.Matrix(), b.Matrix()
+	for i := range ma {
+		for j := range ma[i] {
+			if math.Abs(ma[i][j]-mb[i][j]) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestEulerFromQuaternionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Euler
+		want Euler // the angles expected back
+	}{
+		{"none", Euler{}, Euler{}},
+		{"quarter turn", Euler{Y: math.Pi / 2}, Euler{Y: math.Pi / 2}},
+		{"past a quarter turn", Euler{Y: 3}, Euler{Y: 3}},
+		{"back past a quarter turn", Euler{Y: -2.5}, Euler{Y: -2.5}},
+		{"half turn", Euler{Y: math.Pi}, Euler{Y: math.Pi}},
+		{"tilted", Euler{X: 0.3, Y: 0.4, Z: -0.2}, Euler{X: 0.3, Y: 0.4, Z: -0.2}},
+		{"tilted past a quarter turn", Euler{X: 0.2, Y: 2.5, Z: 0.1}, Euler{X: 0.2, Y: 2.5, Z: 0.1}},
+		{"upside down", Euler{X: math.Pi, Y: 0.5}, Euler{X: math.Pi, Y: 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EulerFromQuaternion(tt.in.Quaternion())
+			if !sameTurn(got, tt.in) {
+				t.Fatalf("EulerFromQuaternion(%v) = %v turns differently", tt.in, got)
+			}
+			for _, d := range []float64{got.X - tt.want.X, got.Y - tt.want.Y, got.Z - tt.want.Z} {
+				if math.Abs(math.Remainder(d, 2*math.Pi)) > 1e-9 {
+					t.Fatalf("EulerFromQuaternion(%v) = %v, want %v", tt.in, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEulerFromQuaternionVerticalTurns(t *testing.T) {
+	for y := -3.1; y <= 3.1; y += 0.1 {
+		got := EulerFromQuaternion(Euler{Y: y}.Quaternion())
+		if got.X != 0 || got.Z != 0 || math.Abs(got.Y-y) > 1e-9 {
+			t.Errorf("turn %.2f came back as %v", y, got)
+		}
+	}
+}
+
+// quaternionMatrix returns the rotation matrix of a unit quaternion
+// (x, y, z, w), row by row.
+func quaternionMatrix(q [4]float64) [3][3]float64 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w)},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w)},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y)},
+	}
+}
+
+func TestEulerMatrixMatchesQuaternion(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Euler
+	}{
+		{"none", Euler{}},
+		{"about X", Euler{X: 0.7}},
+		{"about Y", Euler{Y: -1.2}},
+		{"about Z", Euler{Z: 2.9}},
+		{"all axes", Euler{X: 0.3, Y: 0.4, Z: -0.2}},
+		{"gimbal lock", Euler{X: 0.5, Y: math.Pi / 2, Z: 0.25}},
+		{"upside down", Euler{X: math.Pi, Y: 0.5, Z: -3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.e.Quaternion()
+			if n := q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3]; math.Abs(n-1) > 1e-12 {
+				t.Fatalf("Quaternion(%v) = %v is not a unit quaternion", tt.e, q)
+			}
+			got, want := tt.e.Matrix(), quaternionMatrix(q)
+			for i := range got {
+				for j := range got[i] {
+					if math.Abs(got[i][j]-want[i][j]) > 1e-12 {
+						t.Fatalf("Matrix(%v) = %v, quaternion gives %v", tt.e, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTransformPoint(t *testing.T) {
+	tr := Transform{
+		Position: Vec3{X: 1, Y: 2, Z: 3},
+		Rotation: Euler{Y: math.Pi / 2},
+		Scale:    Vec3{X: 2, Y: 1, Z: 1},
+	}
+	// Scaled to (2, 0, 0), turned a quarter about Y to (0, 0, -2), moved.
+	got := tr.Point(Vec3{X: 1})
+	want := Vec3{X: 1, Y: 2, Z: 1}
+	if math.Abs(got.X-want.X)+math.Abs(got.Y-want.Y)+math.Abs(got.Z-want.Z) > 1e-12 {
+		t.Errorf("Point = %v, want %v", got, want)
+	}
+}
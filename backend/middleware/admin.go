@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"backend/db"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// IsAdmin reports whether the authenticated user may manage the shared
+// catalog. The answer is kept on the context for the rest of the request.
+func IsAdmin(c *gin.Context) (bool, error) {
+	if admin, ok := c.Get("is_admin"); ok {
+		return admin.(bool), nil
+	}
+	userID, ok := c.Get("user_id")
+	id, isNumber := userID.(float64)
+	if !ok || !isNumber {
+		return false, nil
+	}
+	var admin bool
+	err := db.DB.QueryRow(`SELECT is_admin FROM users WHERE id = $1`, int(id)).Scan(&admin)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	c.Set("is_admin", admin)
+	return admin, nil
+}
+
+// AdminMiddleware lets only administrators through. It goes after
+// AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := IsAdmin(c)
+		if err != nil {
+			log.Printf("Database query error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+			c.Abort()
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Administrator rights required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
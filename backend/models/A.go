@@ -6,4 +6,7 @@ type A struct {
 	Object    string `json:"object"`    // Path to the .obj file
 	Thumbnail string `json:"thumbnail"` // Path to the thumbnail image
 	Texture   string `json:"texture"`   // Path to the texture image
+	Glb       string `json:"glb"`       // Path to the binary glTF conversion, if any
+	ModelURL  string `json:"model_url"` // Model in the format the client asked for
+	Format    string `json:"format"`    // "obj" or "glb"
 }
@@ -0,0 +1,27 @@
+package models
+
+// BOMLine is one catalog item (and finish) in a project's bill of materials.
+type BOMLine struct {
+	FurnitureID int     `json:"furniture_id"`
+	VariantID   *int    `json:"variant_id"`
+	Name        string  `json:"name"`
+	VariantName string  `json:"variant_name"`
+	SKU         string  `json:"sku"`
+	Supplier    string  `json:"supplier"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Currency    string  `json:"currency"`
+	LineTotal   float64 `json:"line_total"`
+}
+
+// BillOfMaterials aggregates the placed furniture of a project.
+type BillOfMaterials struct {
+	ProjectID        int                `json:"project_id"`
+	ProjectName      string             `json:"project_name"`
+	Lines            []BOMLine          `json:"lines"`
+	ItemCount        int                `json:"item_count"`
+	TotalsByCurrency map[string]float64 `json:"totals_by_currency"`
+	// GrandTotal and Currency are only set when every line shares a currency.
+	GrandTotal *float64 `json:"grand_total"`
+	Currency   string   `json:"currency"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// CategorySpend is the spend on one catalog category within a project.
+type CategorySpend struct {
+	Category string  `json:"category"` // "" for uncategorised items
+	Items    int     `json:"items"`
+	Spent    float64 `json:"spent"`
+}
+
+// BudgetReport compares a project's placed furniture against its budget.
+// Only items priced in the budget currency are counted towards Spent.
+type BudgetReport struct {
+	ProjectID  int             `json:"project_id"`
+	Budget     *float64        `json:"budget"`
+	Currency   string          `json:"currency"`
+	Spent      float64         `json:"spent"`
+	Remaining  *float64        `json:"remaining"`
+	OverBudget bool            `json:"over_budget"`
+	ByCategory []CategorySpend `json:"by_category"`
+	Warnings   []string        `json:"warnings"`
+}
+
+// BudgetImpact describes how adding an item changes a project's spend.
+type BudgetImpact struct {
+	ItemPrice   float64  `json:"item_price"`
+	Currency    string   `json:"currency"`
+	SpentBefore float64  `json:"spent_before"`
+	SpentAfter  float64  `json:"spent_after"`
+	Budget      *float64 `json:"budget"`
+	Remaining   *float64 `json:"remaining"`
+}
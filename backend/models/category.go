@@ -0,0 +1,7 @@
+package models
+
+type FurnitureCategory struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Count int    `json:"count"` // number of catalog items in the category
+}
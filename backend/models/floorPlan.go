@@ -0,0 +1,34 @@
+package models
+
+// FloorPoint is a corner of a floor plan in metres, on the ground plane:
+// X to the right and Z towards the viewer, as in the editor.
+type FloorPoint struct {
+	X float64 `json:"x"`
+	Z float64 `json:"z"`
+}
+
+// FloorPlan describes a generated room: the inside outline of its walls and
+// their height and thickness in metres.
+type FloorPlan struct {
+	Floor         []FloorPoint `json:"floor"`
+	WallHeight    float64      `json:"wall_height"`
+	WallThickness float64      `json:"wall_thickness"`
+}
+
+// RoomOpening is a door, window or plain opening in a room wall. Wall is the
+// index of the wall running from floor corner Wall to the next corner;
+// Offset is measured along it from that corner to the near edge of the
+// opening. Sizes are in metres; SillHeight is 0 for doors.
+type RoomOpening struct {
+	ID         int     `json:"id"`
+	Type       string  `json:"type"` // "door", "window" or "opening"
+	Wall       int     `json:"wall"`
+	Offset     float64 `json:"offset"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	SillHeight float64 `json:"sill_height"`
+	// Swing is the side a door is hinged on, seen from inside the room:
+	// "left" or "right". Doors swing into the room; empty for sliding doors
+	// and for windows and openings.
+	Swing string `json:"swing,omitempty"`
+}
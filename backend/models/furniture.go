@@ -1,12 +1,61 @@
 package models
 
 type PlacedFurniture struct {
-	ID          int       `json:"id"`
-	ProjectID   int       `json:"project_id"`
-	FurnitureID int       `json:"furniture_id"`
-	X           float64   `json:"x"`
-	Y           float64   `json:"y"`
-	Z           float64   `json:"z"`
-	Rotation    float64   `json:"rotation"`
-	Furniture   Furniture `json:"furniture"` // embedded Furniture details
+	ID          int     `json:"id"`
+	ProjectID   int     `json:"project_id"`
+	FurnitureID int     `json:"furniture_id"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Z           float64 `json:"z"`
+	// Rotation is the turn about the vertical axis in radians. With
+	// RotationX and RotationZ it makes XYZ Euler angles, as in three.js.
+	Rotation  float64 `json:"rotation"`
+	RotationX float64 `json:"rotation_x"`
+	RotationZ float64 `json:"rotation_z"`
+	// Quaternion is the same orientation as (x, y, z, w). Requests may send
+	// it instead of the Euler angles.
+	Quaternion [4]float64 `json:"quaternion"`
+	// ScaleX, ScaleY and ScaleZ stretch the model along its own axes; 1 is
+	// the catalog size, and 0 in requests means 1.
+	ScaleX    float64           `json:"scale_x"`
+	ScaleY    float64           `json:"scale_y"`
+	ScaleZ    float64           `json:"scale_z"`
+	VariantID *int              `json:"variant_id"` // nil when the default finish is used
+	Variant   *FurnitureVariant `json:"variant,omitempty"`
+	Furniture Furniture         `json:"furniture"` // embedded Furniture details
+	// Warnings and Conflicts are only set in responses to AddPlacedFurniture
+	// and UpdateFurniturePosition, BudgetImpact only to AddPlacedFurniture.
+	Warnings     []string            `json:"warnings,omitempty"`
+	Conflicts    []PlacementConflict `json:"conflicts,omitempty"`
+	BudgetImpact *BudgetImpact       `json:"budget_impact,omitempty"`
+}
+
+// PlacementConflict is something a placed item runs into: another item
+// ("overlap"), the walls ("out_of_bounds") or the space a door or opening
+// needs ("blocks_opening"). Distance is how far it reaches in, in metres.
+type PlacementConflict struct {
+	Type              string  `json:"type"`
+	PlacedFurnitureID int     `json:"placed_furniture_id,omitempty"` // the item overlapped
+	OpeningID         int     `json:"opening_id,omitempty"`          // the opening blocked
+	Name              string  `json:"name,omitempty"`
+	Distance          float64 `json:"distance"`
+}
+
+// PlacementSnap is one adjustment made when snapping a placement: turned to
+// an angle step ("rotation"), pulled against a wall ("wall") or into its
+// corner ("corner"), moved onto the grid ("grid") or lined up with another
+// item ("item").
+type PlacementSnap struct {
+	Type              string `json:"type"`
+	Wall              *int   `json:"wall,omitempty"`
+	PlacedFurnitureID int    `json:"placed_furniture_id,omitempty"`
+	Name              string `json:"name,omitempty"`
+}
+
+// SnappedPlacement is a proposed placement after snapping, with the snaps
+// made and what it still runs into.
+type SnappedPlacement struct {
+	Placement PlacedFurniture     `json:"placement"`
+	Snaps     []PlacementSnap     `json:"snaps"`
+	Conflicts []PlacementConflict `json:"conflicts"`
 }
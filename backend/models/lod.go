@@ -0,0 +1,11 @@
+package models
+
+// FurnitureLOD is a simplified version of a catalog model. Clients draw the
+// full model up close and switch to a level once the camera is at least
+// MinDistance metres away.
+type FurnitureLOD struct {
+	Level       int     `json:"level"` // 1 is the most detailed
+	FaceCount   int     `json:"face_count"`
+	URL         string  `json:"url"` // binary glTF
+	MinDistance float64 `json:"min_distance"`
+}
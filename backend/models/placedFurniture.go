@@ -1,9 +1,31 @@
 package models
 
 type Furniture struct {
-	ID            int    `json:"id"`
-	Name          string `json:"name"`
-	ObjFilePath   string `json:"obj_file_path"`
-	TexturePath   string `json:"texture_path"`
-	ThumbnailPath string `json:"thumbnail_path"`
+	ID               int                `json:"id"`
+	Name             string             `json:"name"`
+	ObjFilePath      string             `json:"obj_file_path"`
+	TexturePath      string             `json:"texture_path"`
+	ThumbnailPath    string             `json:"thumbnail_path"`
+	GlbFilePath      string             `json:"glb_file_path"` // binary glTF conversion, empty until converted
+	ModelURL         string             `json:"model_url"`     // model in the format the client asked for
+	ModelFormat      string             `json:"model_format"`  // "obj" or "glb"
+	VertexCount      int                `json:"vertex_count"`
+	FaceCount        int                `json:"face_count"`        // triangulated face count
+	BoundsWidth      float64            `json:"bounds_width"`      // X extent, in Units
+	BoundsHeight     float64            `json:"bounds_height"`     // Y extent, in Units
+	BoundsDepth      float64            `json:"bounds_depth"`      // Z extent, in Units
+	TextureFiles     []string           `json:"texture_files"`     // textures referenced by the MTL
+	Units            string             `json:"units"`             // m, cm, mm, in or ft
+	Width            float64            `json:"width"`             // metres along X
+	Depth            float64            `json:"depth"`             // metres along Z
+	Height           float64            `json:"height"`            // metres along Y
+	DimensionsSource string             `json:"dimensions_source"` // "model" or "manual"
+	Category         string             `json:"category"`          // slug from furniture_category
+	Tags             []string           `json:"tags"`
+	Variants         []FurnitureVariant `json:"variants"`
+	LODs             []FurnitureLOD     `json:"lods"` // ordered from most to least detailed
+	Price            float64            `json:"price"`
+	Currency         string             `json:"currency"` // ISO 4217 code
+	SKU              string             `json:"sku"`
+	Supplier         string             `json:"supplier"`
 }
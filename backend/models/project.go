@@ -1,9 +1,12 @@
 package models
 
 type Project struct {
-	ID          int    `json:"id"`
-	User        int    `json:"user_id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Room        int    `json:"room_layout_id"`
+	ID             int      `json:"id"`
+	User           int      `json:"user_id"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Room           int      `json:"room_layout_id"`
+	Budget         *float64 `json:"budget"`          // nil when the project has no budget
+	BudgetCurrency string   `json:"budget_currency"` // ISO 4217 code
+	ThumbnailURL   string   `json:"thumbnail_url"`   // rendered preview, empty until the first save renders it
 }
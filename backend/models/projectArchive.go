@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// ProjectArchiveFormat and ProjectArchiveVersion identify the project.json
+// manifest of a project archive. The version goes up whenever a change would
+// make older servers misread an archive.
+const (
+	ProjectArchiveFormat  = "room-design-project"
+	ProjectArchiveVersion = 2
+)
+
+// ProjectArchive is the manifest of a portable project archive: a ZIP with
+// project.json and, optionally, the model files of the catalog items under
+// assets/. IDs are those of the exporting server and are remapped on import.
+type ProjectArchive struct {
+	Format     string              `json:"format"`
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Project    ArchivedProject     `json:"project"`
+	Room       *ArchivedRoom       `json:"room"` // nil when the project has no room
+	Catalog    []ArchivedFurniture `json:"catalog"`
+	Placements []ArchivedPlacement `json:"placements"`
+}
+
+// ArchivedProject holds the project fields that travel with an archive.
+type ArchivedProject struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Budget         *float64 `json:"budget"`
+	BudgetCurrency string   `json:"budget_currency"`
+}
+
+// ArchivedRoom is the room of an archived project. Rooms with a floor plan
+// were generated by a user and are generated again on import; catalog rooms
+// are matched by name.
+type ArchivedRoom struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	Width         float64       `json:"width"`
+	Depth         float64       `json:"depth"`
+	CeilingHeight float64       `json:"ceiling_height"`
+	FloorPlan     *FloorPlan    `json:"floor_plan,omitempty"`
+	Openings      []RoomOpening `json:"openings,omitempty"`
+}
+
+// ArchivedFurniture is a catalog item used by an archived project. It is
+// matched by SKU on import, or by name when it has none. The file fields are
+// paths inside the archive, set when the model files were exported.
+type ArchivedFurniture struct {
+	ID        int      `json:"id"`
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	Width     float64  `json:"width"`
+	Depth     float64  `json:"depth"`
+	Height    float64  `json:"height"`
+	Price     float64  `json:"price"`
+	Currency  string   `json:"currency"`
+	Supplier  string   `json:"supplier"`
+	Obj       string   `json:"obj,omitempty"`
+	Texture   string   `json:"texture,omitempty"`
+	Thumbnail string   `json:"thumbnail,omitempty"`
+}
+
+// ArchivedPlacement is a placed item of an archived project. Furniture is
+// the ID of its ArchivedFurniture; Variant names the chosen finish, empty
+// for the default one. The transform is as in PlacedFurniture; version 1
+// archives only have the turn about the vertical axis.
+type ArchivedPlacement struct {
+	Furniture int     `json:"furniture"`
+	Variant   string  `json:"variant,omitempty"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	Rotation  float64 `json:"rotation"`
+	RotationX float64 `json:"rotation_x"`
+	RotationZ float64 `json:"rotation_z"`
+	ScaleX    float64 `json:"scale_x"`
+	ScaleY    float64 `json:"scale_y"`
+	ScaleZ    float64 `json:"scale_z"`
+}
+
+// MissingArchiveItem is something an imported archive refers to that could
+// not be found or created on this server.
+type MissingArchiveItem struct {
+	Type       string `json:"type"` // "room", "furniture" or "variant"
+	ID         int    `json:"id"`   // in the archive
+	SKU        string `json:"sku,omitempty"`
+	Name       string `json:"name"`
+	Placements int    `json:"placements,omitempty"` // placements affected
+	Reason     string `json:"reason"`
+}
+
+// ProjectImportReport describes the project created from an archive.
+type ProjectImportReport struct {
+	Project Project `json:"project"`
+	Placed  int     `json:"placed"`
+	Skipped int     `json:"skipped"` // placements of items that are missing
+	// FurnitureIDs maps the archive's catalog IDs to this server's.
+	FurnitureIDs     map[int]int          `json:"furniture_ids"`
+	CreatedFurniture []int                `json:"created_furniture"` // recreated from archived files
+	Missing          []MissingArchiveItem `json:"missing"`
+}
@@ -1,9 +1,23 @@
 package models
 
 type Room struct {
-	ID        int    `json:"id"`
-	Object    string `json:"obj_file_path"`
-	Texture   string `json:"texture_path"`
-	Thumbnail string `json:"thumbnail_path"`
-	Name      string `json:"name"`
+	ID               int     `json:"id"`
+	Object           string  `json:"obj_file_path"`
+	Texture          string  `json:"texture_path"`
+	Thumbnail        string  `json:"thumbnail_path"`
+	Glb              string  `json:"glb_file_path"` // binary glTF conversion, empty until converted
+	ModelURL         string  `json:"model_url"`     // model in the format the client asked for
+	ModelFormat      string  `json:"model_format"`  // "obj" or "glb"
+	Name             string  `json:"name"`
+	Width            float64 `json:"width"`             // metres along X
+	Depth            float64 `json:"depth"`             // metres along Z
+	CeilingHeight    float64 `json:"ceiling_height"`    // metres along Y
+	FloorArea        float64 `json:"floor_area"`        // square metres
+	DimensionsSource string  `json:"dimensions_source"` // "model" or "manual"
+	// OwnerID and FloorPlan are set on rooms generated by a user; catalog
+	// rooms have neither.
+	OwnerID   *int       `json:"owner_id,omitempty"`
+	FloorPlan *FloorPlan `json:"floor_plan,omitempty"`
+	// Openings are only loaded for single rooms.
+	Openings []RoomOpening `json:"openings,omitempty"`
 }
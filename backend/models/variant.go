@@ -0,0 +1,16 @@
+package models
+
+// FurnitureVariant is an alternative material set for a catalog item. It shares
+// the item's geometry and only swaps textures and materials.
+type FurnitureVariant struct {
+	ID            int      `json:"id"`
+	FurnitureID   int      `json:"furniture_id"`
+	Name          string   `json:"name"`
+	Material      string   `json:"material"` // e.g. oak, walnut, velvet
+	Color         string   `json:"color"`
+	Finish        string   `json:"finish"` // e.g. matte, gloss, oiled
+	TexturePath   string   `json:"texture_path"`
+	MtlFilePath   string   `json:"mtl_file_path"`
+	ThumbnailPath string   `json:"thumbnail_path"`
+	TextureFiles  []string `json:"texture_files"`
+}
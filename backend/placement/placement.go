@@ -0,0 +1,258 @@
+// Package placement checks where furniture is placed in a room: against the
+// other items, the walls and the space doors need.
+package placement
+
+import (
+	"backend/floorplan"
+	"backend/mesh"
+	"backend/models"
+	"fmt"
+	"math"
+)
+
+// Tolerance is how far, in metres, items may reach into each other or the
+// walls before it counts, so items pushed flush against one another are
+// fine.
+const Tolerance = 0.01
+
+// Clearances in front of openings, in metres. Hinged doors need their swing,
+// which is as deep as the door is wide.
+const (
+	slidingClearance = 0.6
+	openingClearance = 0.6
+)
+
+// Box is a placed item seen as a box: a Width x Depth footprint centred on
+// X/Z and turned by Rotation radians about the vertical axis, as in the
+// editor, spanning Bottom to Top in height.
+type Box struct {
+	ID           int
+	Name         string
+	X, Z         float64
+	Rotation     float64
+	Width, Depth float64
+	Bottom, Top  float64
+}
+
+// Footprint returns the box a model covers when placed by t, given its
+// bounds in metres around its own origin: the rectangle around it seen from
+// above, lined up with the way it faces, and the heights it reaches. Items
+// tilted onto their side or back get the footprint they then cover.
+func Footprint(t mesh.Transform, bounds mesh.Box) Box {
+	// The heading is where the front (+Z) points, or for items facing
+	// straight up or down, where their side (+X) points.
+	heading := t.Direction(mesh.Vec3{Z: 1})
+	rotation := math.Atan2(heading.X, heading.Z)
+	if math.Hypot(heading.X, heading.Z) < 1e-6 {
+		side := t.Direction(mesh.Vec3{X: 1})
+		rotation = math.Atan2(-side.Z, side.X)
+	}
+	sin, cos := math.Sincos(rotation)
+	across := mesh.Vec3{X: cos, Z: -sin} // the footprint's width direction
+	along := mesh.Vec3{X: sin, Z: cos}   // and its depth direction
+
+	centre := t.Point(mesh.Vec3{
+		X: (bounds.Min.X + bounds.Max.X) / 2,
+		Y: (bounds.Min.Y + bounds.Max.Y) / 2,
+		Z: (bounds.Min.Z + bounds.Max.Z) / 2,
+	})
+	size := bounds.Size()
+	box := Box{X: centre.X, Z: centre.Z, Rotation: rotation}
+	height := 0.0
+	for i, axis := range []mesh.Vec3{{X: 1}, {Y: 1}, {Z: 1}} {
+		length := []float64{size.X * t.Scale.X, size.Y * t.Scale.Y, size.Z * t.Scale.Z}[i]
+		d := t.Direction(axis)
+		box.Width += math.Abs(d.X*across.X+d.Z*across.Z) * length
+		box.Depth += math.Abs(d.X*along.X+d.Z*along.Z) * length
+		height += math.Abs(d.Y) * length
+	}
+	box.Bottom, box.Top = centre.Y-height/2, centre.Y+height/2
+	return box
+}
+
+// axes returns the unit width and depth directions of the footprint.
+func (b Box) axes() (across, along models.FloorPoint) {
+	sin, cos := math.Sincos(b.Rotation)
+	return models.FloorPoint{X: cos, Z: -sin}, models.FloorPoint{X: sin, Z: cos}
+}
+
+// Corners returns the corners of the footprint, front edge first.
+func (b Box) Corners() []models.FloorPoint {
+	across, along := b.axes()
+	hw, hd := b.Width/2, b.Depth/2
+	var corners []models.FloorPoint
+	for _, c := range [][2]float64{{-hw, hd}, {hw, hd}, {hw, -hd}, {-hw, -hd}} {
+		corners = append(corners, models.FloorPoint{
+			X: b.X + c[0]*across.X + c[1]*along.X,
+			Z: b.Z + c[0]*across.Z + c[1]*along.Z,
+		})
+	}
+	return corners
+}
+
+// project returns the range the footprint covers along a unit axis.
+func (b Box) project(axis models.FloorPoint) (lo, hi float64) {
+	across, along := b.axes()
+	centre := b.X*axis.X + b.Z*axis.Z
+	reach := math.Abs(across.X*axis.X+across.Z*axis.Z)*b.Width/2 + math.Abs(along.X*axis.X+along.Z*axis.Z)*b.Depth/2
+	return centre - reach, centre + reach
+}
+
+// Overlap returns how far two boxes reach into each other: the smallest
+// push, in metres, that would separate them, or 0 when they are apart.
+func Overlap(a, b Box) float64 {
+	depth := math.Min(a.Top-b.Bottom, b.Top-a.Bottom)
+	if depth <= 0 {
+		return 0
+	}
+	aAcross, aAlong := a.axes()
+	bAcross, bAlong := b.axes()
+	for _, axis := range []models.FloorPoint{aAcross, aAlong, bAcross, bAlong} {
+		aLo, aHi := a.project(axis)
+		bLo, bHi := b.project(axis)
+		// Pushing either way along the axis separates them.
+		d := math.Min(aHi-bLo, bHi-aLo)
+		if d <= 0 {
+			return 0
+		}
+		depth = math.Min(depth, d)
+	}
+	return depth
+}
+
+// Room is the space items are placed in: a normalized outline with its
+// wall height and the openings in its walls.
+type Room struct {
+	Plan     models.FloorPlan
+	Openings []models.RoomOpening
+}
+
+// outside returns how far the footprint reaches out of the room, 0 when it
+// is inside.
+func (r Room) outside(b Box) float64 {
+	floor := r.Plan.Floor
+	worst := 0.0
+	for _, p := range b.Corners() {
+		if !insidePolygon(floor, p) {
+			worst = math.Max(worst, distanceToOutline(floor, p))
+		}
+	}
+	// A corner of the room poking into the footprint, as in L-shaped rooms,
+	// puts part of the item in the wall although its corners are inside.
+	across, along := b.axes()
+	for _, p := range floor {
+		dx, dz := p.X-b.X, p.Z-b.Z
+		u := b.Width/2 - math.Abs(dx*across.X+dz*across.Z)
+		v := b.Depth/2 - math.Abs(dx*along.X+dz*along.Z)
+		if u > 0 && v > 0 {
+			worst = math.Max(worst, math.Min(u, v))
+		}
+	}
+	return worst
+}
+
+// clearances returns the space in front of each door and plain opening
+// that has to stay free, as boxes standing on the floor up to the top of
+// the opening. Windows need none.
+func (r Room) clearances() []Box {
+	walls := floorplan.Walls(r.Plan)
+	var boxes []Box
+	for _, o := range r.Openings {
+		if o.Wall < 0 || o.Wall >= len(walls) || o.Type == "window" {
+			continue
+		}
+		depth := openingClearance
+		if o.Type == "door" {
+			depth = slidingClearance
+			if o.Swing != "" {
+				depth = o.Width
+			}
+		}
+		w := walls[o.Wall]
+		mid := w.Inside(o.Offset + o.Width/2)
+		boxes = append(boxes, Box{
+			ID:       o.ID,
+			Name:     fmt.Sprintf("%s on wall %d", o.Type, o.Wall),
+			X:        mid.X - w.Outward.X*depth/2,
+			Z:        mid.Z - w.Outward.Z*depth/2,
+			Rotation: math.Atan2(-w.Along.Z, w.Along.X),
+			Width:    o.Width,
+			Depth:    depth,
+			Top:      o.SillHeight + o.Height,
+		})
+	}
+	return boxes
+}
+
+// Check lists what the placed box runs into: other items, the walls of the
+// room and the space in front of its doors. room is nil when the project
+// has no room with known bounds.
+func Check(box Box, others []Box, room *Room) []models.PlacementConflict {
+	var conflicts []models.PlacementConflict
+	for _, other := range others {
+		if d := Overlap(box, other); d > Tolerance {
+			conflicts = append(conflicts, models.PlacementConflict{
+				Type: "overlap", PlacedFurnitureID: other.ID, Name: other.Name, Distance: round(d),
+			})
+		}
+	}
+	if room == nil {
+		return conflicts
+	}
+	if d := room.outside(box); d > Tolerance {
+		conflicts = append(conflicts, models.PlacementConflict{Type: "out_of_bounds", Distance: round(d)})
+	}
+	for _, clearance := range room.clearances() {
+		if d := Overlap(box, clearance); d > Tolerance {
+			conflicts = append(conflicts, models.PlacementConflict{
+				Type: "blocks_opening", OpeningID: clearance.ID, Name: clearance.Name, Distance: round(d),
+			})
+		}
+	}
+	return conflicts
+}
+
+// Describe returns a conflict as a sentence for warnings.
+func Describe(c models.PlacementConflict) string {
+	switch c.Type {
+	case "overlap":
+		return fmt.Sprintf("Overlaps %s (placed item %d) by %.2f m", c.Name, c.PlacedFurnitureID, c.Distance)
+	case "out_of_bounds":
+		return fmt.Sprintf("Reaches %.2f m into the walls or outside the room", c.Distance)
+	case "blocks_opening":
+		return fmt.Sprintf("Blocks the %s by %.2f m", c.Name, c.Distance)
+	}
+	return c.Type
+}
+
+// round keeps distances to the millimetre.
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// insidePolygon reports whether p lies inside the outline.
+func insidePolygon(floor []models.FloorPoint, p models.FloorPoint) bool {
+	inside := false
+	for i, a := range floor {
+		b := floor[(i+1)%len(floor)]
+		if (a.Z > p.Z) != (b.Z > p.Z) && p.X < a.X+(p.Z-a.Z)*(b.X-a.X)/(b.Z-a.Z) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// distanceToOutline returns the distance from p to the nearest wall face.
+func distanceToOutline(floor []models.FloorPoint, p models.FloorPoint) float64 {
+	best := math.Inf(1)
+	for i, a := range floor {
+		b := floor[(i+1)%len(floor)]
+		dx, dz := b.X-a.X, b.Z-a.Z
+		t := 0.0
+		if l := dx*dx + dz*dz; l > 0 {
+			t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Z-a.Z)*dz)/l))
+		}
+		best = math.Min(best, math.Hypot(p.X-a.X-t*dx, p.Z-a.Z-t*dz))
+	}
+	return best
+}
@@ -0,0 +1,179 @@
+package placement
+
+import (
+	"backend/floorplan"
+	"backend/mesh"
+	"backend/models"
+	"math"
+	"testing"
+)
+
+// room returns a normalized room with the given outline and no openings.
+func room(t *testing.T, corners ...models.FloorPoint) *Room {
+	t.Helper()
+	plan, err := floorplan.Normalize(models.FloorPlan{Floor: corners})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Room{Plan: plan}
+}
+
+// lShapedRoom is 4 x 4 metres with the 2 x 2 quarter beyond (2, 2) cut out.
+func lShapedRoom(t *testing.T) *Room {
+	return room(t,
+		models.FloorPoint{X: 0, Z: 0}, models.FloorPoint{X: 4, Z: 0}, models.FloorPoint{X: 4, Z: 2},
+		models.FloorPoint{X: 2, Z: 2}, models.FloorPoint{X: 2, Z: 4}, models.FloorPoint{X: 0, Z: 4})
+}
+
+// item returns a box standing on the floor, one metre high.
+func item(x, z, rotation, width, depth float64) Box {
+	return Box{X: x, Z: z, Rotation: rotation, Width: width, Depth: depth, Top: 1}
+}
+
+func TestFootprint(t *testing.T) {
+	centred := mesh.Box{Min: mesh.Vec3{X: -0.5, Z: -1}, Max: mesh.Vec3{X: 0.5, Y: 0.8, Z: 1}}
+	corner := mesh.Box{Max: mesh.Vec3{X: 1, Y: 0.8, Z: 2}}
+	tests := []struct {
+		name   string
+		t      mesh.Transform
+		bounds mesh.Box
+		want   Box
+	}{
+		{
+			name:   "centred model",
+			t:      mesh.Transform{Position: mesh.Vec3{X: 5, Z: 5}, Scale: mesh.Vec3{X: 1, Y: 1, Z: 1}},
+			bounds: centred,
+			want:   Box{X: 5, Z: 5, Width: 1, Depth: 2, Top: 0.8},
+		},
+		{
+			name:   "model starting at its origin",
+			t:      mesh.Transform{Position: mesh.Vec3{X: 5, Z: 5}, Scale: mesh.Vec3{X: 1, Y: 1, Z: 1}},
+			bounds: corner,
+			want:   Box{X: 5.5, Z: 6, Width: 1, Depth: 2, Top: 0.8},
+		},
+		{
+			name: "turned model starting at its origin",
+			t: mesh.Transform{
+				Position: mesh.Vec3{X: 5, Z: 5}, Rotation: mesh.Euler{Y: math.Pi / 2}, Scale: mesh.Vec3{X: 1, Y: 1, Z: 1},
+			},
+			bounds: corner,
+			want:   Box{X: 6, Z: 4.5, Rotation: math.Pi / 2, Width: 1, Depth: 2, Top: 0.8},
+		},
+		{
+			name:   "scaled",
+			t:      mesh.Transform{Position: mesh.Vec3{Y: 1}, Scale: mesh.Vec3{X: 2, Y: 0.5, Z: 1}},
+			bounds: corner,
+			want:   Box{X: 1, Z: 1, Width: 2, Depth: 2, Bottom: 1, Top: 1.4},
+		},
+		{
+			name: "tipped onto its back",
+			t: mesh.Transform{
+				Position: mesh.Vec3{Y: 0.5}, Rotation: mesh.Euler{X: -math.Pi / 2}, Scale: mesh.Vec3{X: 1, Y: 1, Z: 1},
+			},
+			bounds: centred,
+			// The front now faces up, so the heading comes from the side.
+			want: Box{X: 0, Z: -0.4, Width: 1, Depth: 0.8, Bottom: -0.5, Top: 1.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Footprint(tt.t, tt.bounds)
+			for _, d := range []float64{
+				got.X - tt.want.X, got.Z - tt.want.Z, math.Remainder(got.Rotation-tt.want.Rotation, 2*math.Pi),
+				got.Width - tt.want.Width, got.Depth - tt.want.Depth, got.Bottom - tt.want.Bottom, got.Top - tt.want.Top,
+			} {
+				if math.Abs(d) > 1e-9 {
+					t.Fatalf("Footprint = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	square := item(0, 0, 0, 1, 1)
+	tests := []struct {
+		name string
+		a, b Box
+		want float64
+	}{
+		{name: "apart", a: square, b: item(2, 0, 0, 1, 1), want: 0},
+		{name: "flush", a: square, b: item(1, 0, 0, 1, 1), want: 0},
+		{name: "side by side", a: square, b: item(0.8, 0, 0, 1, 1), want: 0.2},
+		{name: "one inside the other", a: square, b: item(0, 0, 0, 0.4, 0.4), want: 0.7},
+		{name: "quarter turned", a: item(0, 0, 0, 2, 0.5), b: item(0, 0.9, math.Pi/2, 2, 0.5), want: 0.35},
+		// The corner of the turned square reaches 1/√2 from its centre.
+		{name: "turned corner", a: square, b: item(1.2, 0, math.Pi/4, 1, 1), want: 0.5 - (1.2 - math.Sqrt2/2)},
+		// Their axis-aligned bounds overlap, but the turned square's
+		// lower-left side keeps them apart.
+		{name: "turned, diagonally apart", a: square, b: item(0.9, 0.9, math.Pi/4, 1, 1), want: 0},
+		{name: "one above the other", a: square, b: Box{Width: 1, Depth: 1, Bottom: 1.2, Top: 2}, want: 0},
+		{name: "reaching into the one above", a: square, b: Box{Width: 1, Depth: 1, Bottom: 0.9, Top: 2}, want: 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, pair := range [][2]Box{{tt.a, tt.b}, {tt.b, tt.a}} {
+				if got := Overlap(pair[0], pair[1]); math.Abs(got-tt.want) > 1e-9 {
+					t.Fatalf("Overlap = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoomOutside(t *testing.T) {
+	rect := room(t, floorplan.RectangleOutline(4, 3)...)
+	tests := []struct {
+		name string
+		room *Room
+		box  Box
+		want float64
+	}{
+		{name: "inside", room: rect, box: item(2, 1.5, 0, 1, 1), want: 0},
+		{name: "against the wall", room: rect, box: item(3.5, 1.5, 0, 1, 1), want: 0},
+		{name: "through the wall", room: rect, box: item(3.7, 1.5, 0, 1, 1), want: 0.2},
+		{name: "turned into the corner", room: rect, box: item(3.5, 2.5, math.Pi/4, 1, 1), want: math.Sqrt2/2 - 0.5},
+		{name: "L inside the long arm", room: lShapedRoom(t), box: item(1, 3, 0, 1, 1.5), want: 0},
+		{name: "L corner in the cut out quarter", room: lShapedRoom(t), box: item(2.2, 2.2, 0, 1, 1), want: 0.7},
+		// All four corners are inside the room, yet the inner corner of
+		// the L pokes 0.2 - 0.1√2 into the back of the long, thin item.
+		{name: "L inner corner poking in", room: lShapedRoom(t), box: item(1.9, 1.9, math.Pi/4, 3, 0.4), want: 0.2 - 0.1*math.Sqrt2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.room.outside(tt.box); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("outside = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r := room(t, floorplan.RectangleOutline(4, 3)...)
+	r.Openings = []models.RoomOpening{{ID: 7, Type: "door", Wall: 0, Offset: 1, Width: 0.8, Height: 2, Swing: "left"}}
+	others := []Box{{ID: 3, Name: "sofa", X: 3, Z: 2, Width: 1, Depth: 1, Top: 1}}
+
+	tests := []struct {
+		name  string
+		box   Box
+		types []string
+	}{
+		{name: "free", box: item(1, 2, 0, 0.5, 0.5)},
+		{name: "on the sofa", box: item(2.7, 2, 0, 1, 1), types: []string{"overlap"}},
+		{name: "in the door swing", box: item(1.4, 0.5, 0, 0.5, 0.5), types: []string{"blocks_opening"}},
+		{name: "through the wall onto the sofa", box: item(3.7, 2, 0, 1, 1), types: []string{"overlap", "out_of_bounds"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := Check(tt.box, others, r)
+			if len(conflicts) != len(tt.types) {
+				t.Fatalf("Check = %+v, want %v", conflicts, tt.types)
+			}
+			for i, c := range conflicts {
+				if c.Type != tt.types[i] {
+					t.Errorf("conflict %d is %q, want %q", i, c.Type, tt.types[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package placement
+
+import (
+	"backend/floorplan"
+	"backend/models"
+	"math"
+)
+
+// alignReach is how far apart, in metres, two items in a row may stand for
+// their edges to be lined up.
+const alignReach = 1.0
+
+// SnapOptions control how a placement is snapped.
+type SnapOptions struct {
+	Grid      float64 // grid spacing in metres; 0 leaves positions off the grid
+	AngleStep float64 // headings turn to multiples of it, in radians; 0 for any
+	Reach     float64 // how far, in metres, items are pulled to a wall or edge
+}
+
+// Snap adjusts a proposed box the way a designer lines furniture up. Within
+// Reach of a wall, the item turns to face into the room with its back flush
+// against the wall, into the corner when one is close, and steps along the
+// wall on the grid. Elsewhere its heading snaps to AngleStep and its centre
+// to the grid. Finally its edges are pulled flush with, or in line with,
+// nearby items facing the same way. room may be nil.
+func Snap(box Box, others []Box, room *Room, opts SnapOptions) (Box, []models.PlacementSnap) {
+	var snaps []models.PlacementSnap
+	wallSnapped := false
+	if room != nil && opts.Reach > 0 {
+		var wallSnaps []models.PlacementSnap
+		box, wallSnaps = snapToWall(box, room.Plan, opts)
+		snaps = append(snaps, wallSnaps...)
+		wallSnapped = len(wallSnaps) > 0
+	}
+	if !wallSnapped {
+		if opts.AngleStep > 0 {
+			turned := math.Round(box.Rotation/opts.AngleStep) * opts.AngleStep
+			if math.Abs(turned-box.Rotation) > 1e-9 {
+				box.Rotation = turned
+				snaps = append(snaps, models.PlacementSnap{Type: "rotation"})
+			}
+		}
+		if opts.Grid > 0 {
+			box.X = math.Round(box.X/opts.Grid) * opts.Grid
+			box.Z = math.Round(box.Z/opts.Grid) * opts.Grid
+			snaps = append(snaps, models.PlacementSnap{Type: "grid"})
+		}
+	}
+	if opts.Reach > 0 {
+		var itemSnaps []models.PlacementSnap
+		box, itemSnaps = snapToItems(box, others, opts.Reach, wallSnapped)
+		snaps = append(snaps, itemSnaps...)
+	}
+	return box, snaps
+}
+
+// snapToWall puts the box against the nearest wall within reach, if any.
+func snapToWall(box Box, plan models.FloorPlan, opts SnapOptions) (Box, []models.PlacementSnap) {
+	walls := floorplan.Walls(plan)
+	best, bestGap := -1, math.Inf(1)
+	var bestAlong float64
+	for i, w := range walls {
+		dx, dz := box.X-w.Start.X, box.Z-w.Start.Z
+		along := dx*w.Along.X + dz*w.Along.Z
+		inside := -(dx*w.Outward.X + dz*w.Outward.Z)
+		if along < 0 || along > w.Length || inside < 0 {
+			continue
+		}
+		// Facing into the room, the item's depth runs across the wall.
+		gap := inside - box.Depth/2
+		if gap <= opts.Reach && math.Abs(gap) < math.Abs(bestGap) {
+			best, bestGap, bestAlong = i, gap, along
+		}
+	}
+	if best < 0 {
+		return box, nil
+	}
+
+	w := walls[best]
+	wall := best
+	snaps := []models.PlacementSnap{{Type: "wall", Wall: &wall}}
+	s := bestAlong
+	if half := box.Width / 2; 2*half <= w.Length {
+		s = math.Max(half, math.Min(w.Length-half, s))
+		switch {
+		case s-half <= opts.Reach:
+			s = half
+			previous := (best + len(walls) - 1) % len(walls)
+			snaps = append(snaps, models.PlacementSnap{Type: "corner", Wall: &previous})
+		case w.Length-half-s <= opts.Reach:
+			s = w.Length - half
+			next := (best + 1) % len(walls)
+			snaps = append(snaps, models.PlacementSnap{Type: "corner", Wall: &next})
+		case opts.Grid > 0:
+			// Step the item's edge along the wall from its start.
+			s = math.Round((s-half)/opts.Grid)*opts.Grid + half
+			s = math.Max(half, math.Min(w.Length-half, s))
+			snaps = append(snaps, models.PlacementSnap{Type: "grid"})
+		}
+	}
+	p := w.Inside(s)
+	box.X = p.X - w.Outward.X*box.Depth/2
+	box.Z = p.Z - w.Outward.Z*box.Depth/2
+	box.Rotation = math.Atan2(-w.Outward.X, -w.Outward.Z)
+	return box, snaps
+}
+
+// snapToItems moves the box so one of its edges is flush with, or in line
+// with, an edge of a nearby item facing the same way or square to it. The
+// smallest move within reach wins on each axis. With onlyAcross set the box
+// only moves sideways, keeping it against its wall.
+func snapToItems(box Box, others []Box, reach float64, onlyAcross bool) (Box, []models.PlacementSnap) {
+	var snaps []models.PlacementSnap
+	across, along := box.axes()
+	axes := []models.FloorPoint{across, along}
+	if onlyAcross {
+		axes = axes[:1]
+	}
+	for k, axis := range axes {
+		other := axes[0]
+		if k == 0 {
+			other = along
+		}
+		best, bestShift := -1, math.Inf(1)
+		for i, item := range others {
+			turn := math.Remainder(item.Rotation-box.Rotation, math.Pi/2)
+			if math.Abs(turn) > 1e-3 {
+				continue
+			}
+			aLo, aHi := box.project(axis)
+			bLo, bHi := item.project(axis)
+			pLo, pHi := box.project(other)
+			qLo, qHi := item.project(other)
+			side := math.Min(pHi, qHi) - math.Max(pLo, qLo)
+
+			var shifts []float64
+			switch {
+			case side > Tolerance:
+				// Side by side: close the gap between them.
+				shifts = []float64{bLo - aHi, bHi - aLo}
+			case side > -alignReach:
+				// One in front of the other: line their edges up.
+				shifts = []float64{bLo - aLo, bHi - aHi}
+			}
+			for _, shift := range shifts {
+				if math.Abs(shift) <= reach && math.Abs(shift) < math.Abs(bestShift) {
+					best, bestShift = i, shift
+				}
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		box.X += axis.X * bestShift
+		box.Z += axis.Z * bestShift
+		snaps = append(snaps, models.PlacementSnap{
+			Type: "item", PlacedFurnitureID: others[best].ID, Name: others[best].Name,
+		})
+	}
+	return box, snaps
+}
@@ -0,0 +1,104 @@
+package placement
+
+import (
+	"backend/floorplan"
+	"math"
+	"testing"
+)
+
+func TestSnap(t *testing.T) {
+	rect := room(t, floorplan.RectangleOutline(4, 3)...)
+	neighbour := Box{ID: 3, Name: "cabinet", X: 3, Z: 0.25, Width: 1, Depth: 0.5, Top: 1}
+	tests := []struct {
+		name   string
+		box    Box
+		others []Box
+		room   *Room
+		opts   SnapOptions
+		want   Box
+		snaps  []string
+	}{
+		{
+			name:  "against a wall",
+			box:   item(2, 0.4, 0.3, 1, 0.5),
+			room:  rect,
+			opts:  SnapOptions{Reach: 0.3},
+			want:  item(2, 0.25, 0, 1, 0.5),
+			snaps: []string{"wall"},
+		},
+		{
+			name:  "out of reach of the walls",
+			box:   item(2, 1.5, 0.3, 1, 0.5),
+			room:  rect,
+			opts:  SnapOptions{Reach: 0.3},
+			want:  item(2, 1.5, 0.3, 1, 0.5),
+			snaps: nil,
+		},
+		{
+			name:  "into a corner",
+			box:   item(0.6, 0.4, 0, 1, 0.5),
+			room:  rect,
+			opts:  SnapOptions{Reach: 0.3},
+			want:  item(0.5, 0.25, 0, 1, 0.5),
+			snaps: []string{"wall", "corner"},
+		},
+		{
+			name:  "along a wall on the grid",
+			box:   item(1.9, 0.4, 0, 1, 0.5),
+			room:  rect,
+			opts:  SnapOptions{Reach: 0.3, Grid: 0.5},
+			want:  item(2, 0.25, 0, 1, 0.5),
+			snaps: []string{"wall", "grid"},
+		},
+		{
+			name:  "onto the grid",
+			box:   item(1.12, 2.37, 0.2, 1, 1),
+			opts:  SnapOptions{Grid: 0.5, AngleStep: math.Pi / 2},
+			want:  item(1, 2.5, 0, 1, 1),
+			snaps: []string{"rotation", "grid"},
+		},
+		{
+			name:   "flush with a neighbour",
+			box:    item(1.8, 0.3, 0, 1, 0.5),
+			others: []Box{neighbour},
+			opts:   SnapOptions{Reach: 0.3},
+			want:   item(2, 0.25, 0, 1, 0.5),
+			snaps:  []string{"item", "item"},
+		},
+		{
+			name:   "against a wall, flush with a neighbour",
+			box:    item(1.85, 0.4, 0, 1, 0.5),
+			others: []Box{neighbour},
+			room:   rect,
+			opts:   SnapOptions{Reach: 0.3},
+			want:   item(2, 0.25, 0, 1, 0.5),
+			snaps:  []string{"wall", "item"},
+		},
+		{
+			name:   "neighbour turned another way",
+			box:    item(1.8, 0.3, 0, 1, 0.5),
+			others: []Box{{X: 3, Z: 0.25, Rotation: math.Pi / 4, Width: 1, Depth: 0.5, Top: 1}},
+			opts:   SnapOptions{Reach: 0.3},
+			want:   item(1.8, 0.3, 0, 1, 0.5),
+			snaps:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, snaps := Snap(tt.box, tt.others, tt.room, tt.opts)
+			for _, d := range []float64{got.X - tt.want.X, got.Z - tt.want.Z, math.Remainder(got.Rotation-tt.want.Rotation, 2*math.Pi)} {
+				if math.Abs(d) > 1e-9 {
+					t.Fatalf("Snap = %+v, want %+v", got, tt.want)
+				}
+			}
+			if len(snaps) != len(tt.snaps) {
+				t.Fatalf("snaps = %+v, want %v", snaps, tt.snaps)
+			}
+			for i, s := range snaps {
+				if s.Type != tt.snaps[i] {
+					t.Errorf("snap %d is %q, want %q", i, s.Type, tt.snaps[i])
+				}
+			}
+		})
+	}
+}
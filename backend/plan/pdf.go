@@ -0,0 +1,157 @@
+package plan
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pointsPerMM converts sheet millimetres to PDF points.
+const pointsPerMM = 72 / 25.4
+
+// winAnsi maps the characters outside Latin-1 that WinAnsiEncoding has to
+// their codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsiEncoding, with
+// characters it lacks replaced by question marks.
+func pdfString(text string) string {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, r := range text {
+		c, ok := winAnsi[r]
+		switch {
+		case ok:
+		case r >= ' ' && r <= '~' || r >= 0xA0 && r <= 0xFF:
+			c = byte(r)
+		default:
+			c = '?'
+		}
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTextString encodes text for the document information dictionary, as
+// UTF-16 with a byte order mark.
+func pdfTextString(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfColor returns the operands of a #rrggbb colour.
+func pdfColor(hex string) string {
+	v, _ := strconv.ParseUint(hex[1:], 16, 32)
+	channel := func(shift uint) string {
+		return strconv.FormatFloat(math.Round(float64(v>>shift&0xff)/255*1000)/1000, 'f', -1, 64)
+	}
+	return channel(16) + " " + channel(8) + " " + channel(0)
+}
+
+// WritePDF writes the sheet as a single page PDF using the standard
+// Helvetica font.
+func (s *Sheet) WritePDF(w io.Writer) error {
+	height := s.Height * pointsPerMM
+	pt := func(p vec) string {
+		return num(p.X*pointsPerMM) + " " + num(height-p.Y*pointsPerMM)
+	}
+
+	var content bytes.Buffer
+	content.WriteString("1 j 1 J\n")
+	for _, sh := range s.shapes {
+		if sh.text != "" {
+			// Sheet angles turn clockwise with Y down; PDF's turn
+			// counter-clockwise with Y up.
+			sin, cos := math.Sincos(-sh.angle * math.Pi / 180)
+			shift := anchorShift(sh.anchor, textWidth(sh.text, sh.size))
+			start := vec{sh.points[0].X - shift*cos, sh.points[0].Y + shift*sin}
+			size := sh.size * pointsPerMM
+			coef := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+			fmt.Fprintf(&content, "0 g BT /F1 %s Tf %s %s %s %s %s Tm %s Tj ET\n",
+				num(size), coef(cos), coef(sin), coef(-sin), coef(cos), pt(start), pdfString(sh.text))
+			continue
+		}
+		if sh.style.Fill != "" {
+			fmt.Fprintf(&content, "%s rg ", pdfColor(sh.style.Fill))
+		}
+		if sh.style.Stroke != "" {
+			fmt.Fprintf(&content, "%s RG %s w ", pdfColor(sh.style.Stroke), num(sh.style.Width*pointsPerMM))
+		}
+		for i, p := range sh.points {
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(&content, "%s %s ", pt(p), op)
+		}
+		if sh.closed {
+			content.WriteString("h ")
+		}
+		switch {
+		case sh.style.Fill != "" && sh.style.Stroke != "":
+			content.WriteString("B\n")
+		case sh.style.Fill != "":
+			content.WriteString("f\n")
+		default:
+			content.WriteString("S\n")
+		}
+	}
+
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	if _, err := zw.Write(content.Bytes()); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+			num(s.Width*pointsPerMM), num(height)),
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title %s >>", pdfTextString(s.Title)),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,335 @@
+// Package plan draws dimensioned 2D floor plans of projects: the room's
+// walls and openings seen from above, the footprint of every placed item,
+// dimension lines and a scale bar, laid out on a printable sheet that can be
+// written as SVG or PDF.
+package plan
+
+import (
+	"backend/floorplan"
+	"backend/models"
+	"fmt"
+	"math"
+)
+
+// Plan is what gets drawn.
+type Plan struct {
+	Title    string // project name
+	RoomName string
+	// Room is the outline of the room, nil when the project has none.
+	Room     *models.FloorPlan
+	Openings []models.RoomOpening
+	Items    []Item
+}
+
+// Item is the footprint of a placed piece of furniture: a Width x Depth
+// rectangle in metres centred on X/Z and turned by Rotation radians about
+// the vertical axis, as in the editor. Its front faces +Z before turning.
+type Item struct {
+	Label          string
+	X, Z, Rotation float64
+	Width, Depth   float64
+}
+
+// Sheet sizes in millimetres, landscape.
+const (
+	a4Width, a4Height = 297.0, 210.0
+	margin            = 12.0 // paper edge to frame
+	dimensionBand     = 14.0 // frame to drawing, room for dimension lines
+	titleHeight       = 18.0
+)
+
+// scales are the drawing scales tried, as 1:n.
+var scales = []float64{10, 20, 25, 50, 100, 200, 250, 500, 1000}
+
+// Colours and line widths in millimetres.
+var (
+	frameStyle     = style{Stroke: "#000000", Width: 0.35}
+	floorStyle     = style{Fill: "#f4f4f2"}
+	wallStyle      = style{Fill: "#3a3a3a", Stroke: "#3a3a3a", Width: 0.1}
+	openingStyle   = style{Stroke: "#3a3a3a", Width: 0.18}
+	itemStyle      = style{Fill: "#e6edf7", Stroke: "#4a6fa5", Width: 0.25}
+	itemFrontStyle = style{Stroke: "#4a6fa5", Width: 0.6}
+	dimensionStyle = style{Stroke: "#000000", Width: 0.13}
+	barDarkStyle   = style{Fill: "#000000", Stroke: "#000000", Width: 0.13}
+	barLightStyle  = style{Fill: "#ffffff", Stroke: "#000000", Width: 0.13}
+)
+
+// layout maps plan coordinates in metres to the sheet.
+type layout struct {
+	sheet  *Sheet
+	scale  float64 // 1:scale
+	min    models.FloorPoint
+	origin vec // sheet position of min
+}
+
+func (l *layout) at(p models.FloorPoint) vec {
+	k := 1000 / l.scale
+	return vec{l.origin.X + (p.X-l.min.X)*k, l.origin.Y + (p.Z-l.min.Z)*k}
+}
+
+// metres converts a length on the sheet to metres in the plan.
+func (l *layout) metres(mm float64) float64 {
+	return mm * l.scale / 1000
+}
+
+func (l *layout) line(style style, points ...models.FloorPoint) {
+	l.sheet.path(style, false, l.points(points)...)
+}
+
+func (l *layout) polygon(style style, points ...models.FloorPoint) {
+	l.sheet.path(style, true, l.points(points)...)
+}
+
+func (l *layout) points(points []models.FloorPoint) []vec {
+	mapped := make([]vec, len(points))
+	for i, p := range points {
+		mapped[i] = l.at(p)
+	}
+	return mapped
+}
+
+// Layout draws a plan on an A4 sheet, turned to suit the plan's shape, at
+// the largest standard scale it fits.
+func Layout(p Plan) *Sheet {
+	var walls []floorplan.Wall
+	if p.Room != nil {
+		walls = floorplan.Walls(*p.Room)
+	}
+	var corners []models.FloorPoint
+	for _, w := range walls {
+		corners = append(corners, w.Start, w.Outside(0))
+	}
+	for _, item := range p.Items {
+		corners = append(corners, item.corners()...)
+	}
+	if len(corners) == 0 {
+		corners = []models.FloorPoint{{X: 0, Z: 0}, {X: 1, Z: 1}}
+	}
+	lo, hi := floorplan.Bounds(corners)
+	extentX, extentZ := math.Max(hi.X-lo.X, 0.01), math.Max(hi.Z-lo.Z, 0.01)
+
+	sheet := &Sheet{Width: a4Width, Height: a4Height, Title: p.Title}
+	if extentZ > extentX {
+		sheet.Width, sheet.Height = a4Height, a4Width
+	}
+	areaWidth := sheet.Width - 2*margin - 2*dimensionBand
+	areaHeight := sheet.Height - 2*margin - titleHeight - 2*dimensionBand
+	needed := math.Max(extentX*1000/areaWidth, extentZ*1000/areaHeight)
+	scale := math.Ceil(needed/1000) * 1000
+	for _, s := range scales {
+		if s >= needed {
+			scale = s
+			break
+		}
+	}
+	l := &layout{sheet: sheet, scale: scale, min: lo}
+	k := 1000 / scale
+	l.origin = vec{
+		margin + dimensionBand + (areaWidth-extentX*k)/2,
+		margin + dimensionBand + (areaHeight-extentZ*k)/2,
+	}
+
+	sheet.path(frameStyle, true, vec{margin, margin}, vec{sheet.Width - margin, margin},
+		vec{sheet.Width - margin, sheet.Height - margin}, vec{margin, sheet.Height - margin})
+
+	if p.Room != nil {
+		l.polygon(floorStyle, p.Room.Floor...)
+	}
+	for _, item := range p.Items {
+		l.item(item)
+	}
+	byWall := map[int][]models.RoomOpening{}
+	for _, o := range p.Openings {
+		if o.Wall >= 0 && o.Wall < len(walls) {
+			byWall[o.Wall] = append(byWall[o.Wall], o)
+		}
+	}
+	for i, w := range walls {
+		for _, piece := range w.Pieces(byWall[i]) {
+			l.polygon(wallStyle, piece[:]...)
+		}
+		for _, o := range byWall[i] {
+			l.opening(w, o)
+		}
+		l.dimension(w)
+	}
+	l.titleBlock(p)
+	return sheet
+}
+
+// corners returns the corners of an item's footprint, front edge first.
+func (item Item) corners() []models.FloorPoint {
+	sin, cos := math.Sincos(item.Rotation)
+	hw, hd := item.Width/2, item.Depth/2
+	var corners []models.FloorPoint
+	for _, c := range [][2]float64{{-hw, hd}, {hw, hd}, {hw, -hd}, {-hw, -hd}} {
+		corners = append(corners, models.FloorPoint{
+			X: item.X + c[0]*cos + c[1]*sin,
+			Z: item.Z - c[0]*sin + c[1]*cos,
+		})
+	}
+	return corners
+}
+
+// item draws a footprint with its front edge marked and its label fitted
+// inside when possible.
+func (l *layout) item(item Item) {
+	corners := item.corners()
+	l.polygon(itemStyle, corners...)
+	l.line(itemFrontStyle, corners[0], corners[1])
+
+	if item.Label == "" {
+		return
+	}
+	// Labels run along the longer side of the footprint.
+	along, across := item.Width, item.Depth
+	angle := -item.Rotation * 180 / math.Pi
+	if item.Depth > item.Width {
+		along, across = across, along
+		angle -= 90
+	}
+	size := math.Min(2.5, across*1000/l.scale*0.6)
+	if w := textWidth(item.Label, 1); w > 0 {
+		size = math.Min(size, along*1000/l.scale*0.9/w)
+	}
+	size = math.Max(size, 1.2)
+	center := l.at(models.FloorPoint{X: item.X, Z: item.Z})
+	l.sheet.text(readable(angle), size, anchorMiddle, center.add(up(readable(angle)).scale(-size*0.35)), item.Label)
+}
+
+// opening draws a door with its swing, a window's glazing lines or nothing
+// for a plain opening.
+func (l *layout) opening(w floorplan.Wall, o models.RoomOpening) {
+	s0, s1 := o.Offset, o.Offset+o.Width
+	inward := models.FloorPoint{X: -w.Outward.X, Z: -w.Outward.Z}
+	out := func(p models.FloorPoint, d float64) models.FloorPoint {
+		return models.FloorPoint{X: p.X + w.Outward.X*d, Z: p.Z + w.Outward.Z*d}
+	}
+	switch o.Type {
+	case floorplan.OpeningWindow:
+		for _, d := range []float64{0, w.Thickness / 2, w.Thickness} {
+			l.line(openingStyle, out(w.Inside(s0), d), out(w.Inside(s1), d))
+		}
+	case floorplan.OpeningDoor:
+		if o.Swing == "" {
+			// Sliding door: two overlapping leaves in the wall.
+			leaf := o.Width * 0.55
+			l.line(openingStyle, out(w.Inside(s0), w.Thickness/3), out(w.Inside(s0+leaf), w.Thickness/3))
+			l.line(openingStyle, out(w.Inside(s1-leaf), 2*w.Thickness/3), out(w.Inside(s1), 2*w.Thickness/3))
+			return
+		}
+		// Seen from inside, Along points to the right.
+		hinge, latch := w.Inside(s0), w.Inside(s1)
+		if o.Swing == "right" {
+			hinge, latch = latch, hinge
+		}
+		open := models.FloorPoint{X: hinge.X + inward.X*o.Width, Z: hinge.Z + inward.Z*o.Width}
+		l.line(openingStyle, hinge, open)
+		l.line(openingStyle, arc(hinge, open, latch, 16)...)
+	}
+}
+
+// arc returns points on the circle around centre from a to b, the short way.
+func arc(centre, a, b models.FloorPoint, segments int) []models.FloorPoint {
+	r := math.Hypot(a.X-centre.X, a.Z-centre.Z)
+	start := math.Atan2(a.Z-centre.Z, a.X-centre.X)
+	sweep := math.Atan2(b.Z-centre.Z, b.X-centre.X) - start
+	sweep = math.Remainder(sweep, 2*math.Pi)
+	points := make([]models.FloorPoint, segments+1)
+	for i := range points {
+		t := start + sweep*float64(i)/float64(segments)
+		points[i] = models.FloorPoint{X: centre.X + r*math.Cos(t), Z: centre.Z + r*math.Sin(t)}
+	}
+	return points
+}
+
+// dimension draws the inside length of a wall on a dimension line outside
+// it, with extension lines and ticks.
+func (l *layout) dimension(w floorplan.Wall) {
+	out := func(p models.FloorPoint, d float64) models.FloorPoint {
+		return models.FloorPoint{X: p.X + w.Outward.X*d, Z: p.Z + w.Outward.Z*d}
+	}
+	gap, offset, overshoot := l.metres(1), w.Thickness+l.metres(7), l.metres(1.5)
+	a, b := out(w.Start, offset), out(w.End, offset)
+	l.line(dimensionStyle, out(w.Start, w.Thickness+gap), out(w.Start, offset+overshoot))
+	l.line(dimensionStyle, out(w.End, w.Thickness+gap), out(w.End, offset+overshoot))
+	l.line(dimensionStyle, a, b)
+
+	pa, pb := l.at(a), l.at(b)
+	dir := pb.sub(pa).scale(1 / pb.sub(pa).length())
+	// Architectural ticks: short strokes at 45 degrees.
+	tick := vec{dir.X - dir.Y, dir.Y + dir.X}.scale(1.2 / math.Sqrt2)
+	for _, p := range []vec{pa, pb} {
+		l.sheet.path(style{Stroke: "#000000", Width: 0.3}, false, p.sub(tick), p.add(tick))
+	}
+
+	angle := readable(math.Atan2(dir.Y, dir.X) * 180 / math.Pi)
+	mid := pa.add(pb).scale(0.5).add(up(angle).scale(0.8))
+	l.sheet.text(angle, 2.2, anchorMiddle, mid, fmt.Sprintf("%.2f", w.Length))
+}
+
+// titleBlock writes the project and room names, totals and the scale bar
+// under the drawing.
+func (l *layout) titleBlock(p Plan) {
+	s := l.sheet
+	top := s.Height - margin - titleHeight
+	s.path(frameStyle, false, vec{margin, top}, vec{s.Width - margin, top})
+
+	title := p.Title
+	if title == "" {
+		title = "Floor plan"
+	}
+	s.text(0, 4.2, anchorStart, vec{margin + 4, top + 7.5}, title)
+	details := fmt.Sprintf("%d items", len(p.Items))
+	if len(p.Items) == 1 {
+		details = "1 item"
+	}
+	if p.Room != nil {
+		details = fmt.Sprintf("%s  |  floor area %.2f m²  |  %s", p.RoomName, floorplan.Area(p.Room.Floor), details)
+	}
+	s.text(0, 2.5, anchorStart, vec{margin + 4, top + 13.5}, details+"  |  dimensions in metres")
+
+	// Five segments of a round length, about 50 mm in all.
+	step := 0.1
+	for _, candidate := range []float64{0.1, 0.2, 0.5, 1, 2, 5, 10, 20, 50, 100} {
+		if 5*candidate*1000/l.scale <= 50 {
+			step = candidate
+		}
+	}
+	segment := step * 1000 / l.scale
+	x0 := s.Width - margin - 8 - 5*segment
+	y := top + 9
+	for i := 0; i < 5; i++ {
+		st := barLightStyle
+		if i%2 == 0 {
+			st = barDarkStyle
+		}
+		x := x0 + float64(i)*segment
+		s.path(st, true, vec{x, y}, vec{x + segment, y}, vec{x + segment, y + 1.8}, vec{x, y + 1.8})
+		s.text(0, 2, anchorMiddle, vec{x, y - 1}, formatLength(float64(i)*step))
+	}
+	s.text(0, 2, anchorMiddle, vec{x0 + 5*segment, y - 1}, formatLength(5*step)+" m")
+	s.text(0, 2.5, anchorEnd, vec{s.Width - margin - 4, top + 15.5}, fmt.Sprintf("Scale 1:%g (A4)", l.scale))
+}
+
+func formatLength(v float64) string {
+	return fmt.Sprintf("%g", math.Round(v*100)/100)
+}
+
+// readable turns a text angle in degrees so the text never reads upside
+// down.
+func readable(angle float64) float64 {
+	angle = math.Remainder(angle, 360)
+	if angle >= 90 {
+		angle -= 180
+	} else if angle < -90 {
+		angle += 180
+	}
+	return angle
+}
+
+// up is the unit vector towards the top of text drawn at angle degrees.
+func up(angle float64) vec {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return vec{sin, -cos}
+}
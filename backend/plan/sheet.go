@@ -0,0 +1,95 @@
+package plan
+
+import "math"
+
+// vec is a position or offset on a sheet, in millimetres from the top left
+// corner with Y pointing down.
+type vec struct {
+	X, Y float64
+}
+
+func (a vec) add(b vec) vec       { return vec{a.X + b.X, a.Y + b.Y} }
+func (a vec) sub(b vec) vec       { return vec{a.X - b.X, a.Y - b.Y} }
+func (a vec) scale(s float64) vec { return vec{a.X * s, a.Y * s} }
+func (a vec) length() float64     { return math.Hypot(a.X, a.Y) }
+
+// style is how a path is drawn: colours as #rrggbb, empty for none, and the
+// stroke width in millimetres.
+type style struct {
+	Stroke, Fill string
+	Width        float64
+}
+
+// Text anchors: where the text position is along the text.
+const (
+	anchorStart = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// shape is a path or a line of text on a sheet.
+type shape struct {
+	style  style
+	points []vec
+	closed bool
+
+	text   string
+	size   float64 // font size in millimetres
+	angle  float64 // degrees, clockwise on the sheet
+	anchor int
+}
+
+// Sheet is a drawn plan: shapes in drawing order on a page of Width x
+// Height millimetres.
+type Sheet struct {
+	Width, Height float64
+	Title         string
+	shapes        []shape
+}
+
+func (s *Sheet) path(style style, closed bool, points ...vec) {
+	if len(points) > 1 {
+		s.shapes = append(s.shapes, shape{style: style, points: points, closed: closed})
+	}
+}
+
+// text adds a line of text in black with its baseline through at.
+func (s *Sheet) text(angle, size float64, anchor int, at vec, text string) {
+	s.shapes = append(s.shapes, shape{points: []vec{at}, text: text, size: size, angle: angle, anchor: anchor})
+}
+
+// helveticaWidths are the advance widths of printable ASCII in Helvetica,
+// in thousandths of the font size, starting at the space.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth is the width of text set in Helvetica at size, in the units of
+// size. Characters outside ASCII count as an average letter.
+func textWidth(text string, size float64) float64 {
+	total := 0
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			total += helveticaWidths[r-' ']
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// anchorShift is how far before its position a text starts.
+func anchorShift(anchor int, width float64) float64 {
+	switch anchor {
+	case anchorMiddle:
+		return width / 2
+	case anchorEnd:
+		return width
+	}
+	return 0
+}
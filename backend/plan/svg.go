@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// num formats a length rounded to a hundredth of a millimetre.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// WriteSVG writes the sheet as an SVG document sized in millimetres.
+func (s *Sheet) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		num(s.Width), num(s.Height), num(s.Width), num(s.Height))
+	if s.Title != "" {
+		bw.WriteString("<title>")
+		xml.EscapeText(bw, []byte(s.Title))
+		bw.WriteString("</title>\n")
+	}
+	fmt.Fprintf(bw, `<rect width="%s" height="%s" fill="#ffffff"/>`+"\n", num(s.Width), num(s.Height))
+
+	for _, sh := range s.shapes {
+		if sh.text != "" {
+			p := sh.points[0]
+			anchor := [...]string{"start", "middle", "end"}[sh.anchor]
+			fmt.Fprintf(bw, `<text x="%s" y="%s" font-family="Helvetica, Arial, sans-serif" font-size="%s" text-anchor="%s"`,
+				num(p.X), num(p.Y), num(sh.size), anchor)
+			if sh.angle != 0 {
+				fmt.Fprintf(bw, ` transform="rotate(%s %s %s)"`, num(sh.angle), num(p.X), num(p.Y))
+			}
+			bw.WriteString(">")
+			xml.EscapeText(bw, []byte(sh.text))
+			bw.WriteString("</text>\n")
+			continue
+		}
+
+		var d strings.Builder
+		for i, p := range sh.points {
+			if i == 0 {
+				d.WriteString("M")
+			} else {
+				d.WriteString(" L")
+			}
+			d.WriteString(num(p.X) + " " + num(p.Y))
+		}
+		if sh.closed {
+			d.WriteString(" Z")
+		}
+		fill, stroke := sh.style.Fill, sh.style.Stroke
+		if fill == "" {
+			fill = "none"
+		}
+		if stroke == "" {
+			stroke = "none"
+		}
+		fmt.Fprintf(bw, `<path d="%s" fill="%s" stroke="%s" stroke-width="%s" stroke-linejoin="round" stroke-linecap="round"/>`+"\n",
+			d.String(), fill, stroke, num(sh.style.Width))
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"backend/mesh"
+	"fmt"
+	"image"
+)
+
+// Part is a scene placed in a larger one by Transform, as the editor places
+// furniture. Use mesh.Identity to leave a scene where it is.
+type Part struct {
+	Scene     Scene
+	Transform mesh.Transform
+}
+
+// Compose merges parts into a single scene. Material and texture names are
+// prefixed per part so parts using the same names keep their own colours.
+func Compose(parts []Part) Scene {
+	out := Scene{
+		Mesh:      &mesh.Mesh{},
+		Materials: map[string]*mesh.Material{},
+		Textures:  map[string]image.Image{},
+	}
+	for i, part := range parts {
+		prefix := fmt.Sprintf("%d/", i)
+		src := part.Scene.Mesh
+
+		pOffset, tOffset, nOffset := len(out.Mesh.Positions), len(out.Mesh.UVs), len(out.Mesh.Normals)
+		for _, p := range src.Positions {
+			out.Mesh.Positions = append(out.Mesh.Positions, part.Transform.Point(p))
+		}
+		out.Mesh.UVs = append(out.Mesh.UVs, src.UVs...)
+		for _, n := range src.Normals {
+			out.Mesh.Normals = append(out.Mesh.Normals, part.Transform.Normal(n))
+		}
+
+		for _, tri := range src.Triangles {
+			for j := range tri.V {
+				tri.V[j].P += pOffset
+				if tri.V[j].T >= 0 {
+					tri.V[j].T += tOffset
+				}
+				if tri.V[j].N >= 0 {
+					tri.V[j].N += nOffset
+				}
+			}
+			tri.Material = prefix + tri.Material
+			out.Mesh.Triangles = append(out.Mesh.Triangles, tri)
+		}
+
+		// Every material of the part, plus the unnamed default, takes the
+		// part's replacement texture when it has one.
+		materials := part.Scene.Materials
+		if part.Scene.Texture != nil {
+			materials = map[string]*mesh.Material{"": {}}
+			for name := range part.Scene.Materials {
+				materials[name] = &mesh.Material{}
+			}
+			for _, tri := range src.Triangles {
+				materials[tri.Material] = &mesh.Material{}
+			}
+			out.Textures[prefix+"texture"] = part.Scene.Texture
+		}
+		for name, mat := range materials {
+			copied := *mat
+			if part.Scene.Texture != nil {
+				copied.Diffuse = [3]float64{1, 1, 1}
+				copied.DiffuseMap = prefix + "texture"
+			} else if copied.DiffuseMap != "" {
+				if tex, ok := part.Scene.Textures[copied.DiffuseMap]; ok {
+					out.Textures[prefix+copied.DiffuseMap] = tex
+				}
+				copied.DiffuseMap = prefix + copied.DiffuseMap
+			}
+			out.Materials[prefix+name] = &copied
+		}
+	}
+	return out
+}
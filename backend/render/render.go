@@ -0,0 +1,286 @@
+// Package render is a small CPU rasterizer that draws shaded previews of
+// meshes, used for catalog thumbnails. It needs no GPU or cgo.
+package render
+
+import (
+	"backend/mesh"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Options describes the camera and output image.
+type Options struct {
+	Width, Height int
+	// Yaw turns the camera around the model's vertical axis and Pitch tilts
+	// it down towards the model, both in degrees.
+	Yaw, Pitch float64
+	// FOV is the vertical field of view in degrees.
+	FOV float64
+	// Supersample renders at this multiple of the output size and averages
+	// down, smoothing edges.
+	Supersample int
+	// CutAbove, when set, skips faces lying entirely above this fraction of
+	// the model's height, so ceilings do not hide the inside of rooms.
+	CutAbove float64
+}
+
+// ProductView is the standard three-quarter catalog camera.
+var ProductView = Options{Width: 512, Height: 512, Yaw: 35, Pitch: 25, FOV: 30, Supersample: 2}
+
+// RoomView looks down into a room so walls do not hide the floor.
+var RoomView = Options{Width: 512, Height: 512, Yaw: 35, Pitch: 55, FOV: 30, Supersample: 2, CutAbove: 0.95}
+
+// ProjectView is the wider view of a furnished room used for project previews.
+var ProjectView = Options{Width: 640, Height: 400, Yaw: 35, Pitch: 60, FOV: 30, Supersample: 2, CutAbove: 0.95}
+
+// Scene is a mesh with the material information needed to colour it.
+type Scene struct {
+	Mesh      *mesh.Mesh
+	Materials map[string]*mesh.Material
+	// Textures holds decoded diffuse maps keyed by Material.DiffuseMap.
+	Textures map[string]image.Image
+	// Texture, when set, replaces every material's diffuse map.
+	Texture image.Image
+}
+
+type vec3 struct{ x, y, z float64 }
+
+func (a vec3) sub(b vec3) vec3      { return vec3{a.x - b.x, a.y - b.y, a.z - b.z} }
+func (a vec3) add(b vec3) vec3      { return vec3{a.x + b.x, a.y + b.y, a.z + b.z} }
+func (a vec3) scale(s float64) vec3 { return vec3{a.x * s, a.y * s, a.z * s} }
+func (a vec3) dot(b vec3) float64   { return a.x*b.x + a.y*b.y + a.z*b.z }
+func (a vec3) cross(b vec3) vec3 {
+	return vec3{a.y*b.z - a.z*b.y, a.z*b.x - a.x*b.z, a.x*b.y - a.y*b.x}
+}
+func (a vec3) normalize() vec3 {
+	l := math.Sqrt(a.dot(a))
+	if l == 0 {
+		return a
+	}
+	return a.scale(1 / l)
+}
+
+func fromMesh(v mesh.Vec3) vec3 { return vec3{v.X, v.Y, v.Z} }
+
+// camera projects world positions to screen pixels.
+type camera struct {
+	eye, right, up, forward vec3
+	focal, cx, cy           float64
+}
+
+func newCamera(bounds mesh.Box, opts Options, width, height int) camera {
+	center := fromMesh(bounds.Min).add(fromMesh(bounds.Max)).scale(0.5)
+	radius := fromMesh(bounds.Max).sub(fromMesh(bounds.Min)).scale(0.5)
+	r := math.Sqrt(radius.dot(radius))
+	if r == 0 {
+		r = 1
+	}
+	yaw := opts.Yaw * math.Pi / 180
+	pitch := opts.Pitch * math.Pi / 180
+	halfFOV := opts.FOV * math.Pi / 360
+	distance := r / math.Sin(halfFOV) * 1.05
+
+	dir := vec3{math.Sin(yaw) * math.Cos(pitch), math.Sin(pitch), math.Cos(yaw) * math.Cos(pitch)}
+	eye := center.add(dir.scale(distance))
+	forward := center.sub(eye).normalize()
+	right := forward.cross(vec3{0, 1, 0}).normalize()
+	up := right.cross(forward)
+	return camera{
+		eye: eye, right: right, up: up, forward: forward,
+		focal: float64(height) / 2 / math.Tan(halfFOV),
+		cx:    float64(width) / 2, cy: float64(height) / 2,
+	}
+}
+
+// project returns screen x, y and view depth of p.
+func (c camera) project(p vec3) (float64, float64, float64) {
+	v := p.sub(c.eye)
+	z := v.dot(c.forward)
+	return c.cx + v.dot(c.right)/z*c.focal, c.cy - v.dot(c.up)/z*c.focal, z
+}
+
+// Render draws the scene and returns an image with a transparent background.
+func Render(scene Scene, opts Options) *image.NRGBA {
+	if opts.Supersample < 1 {
+		opts.Supersample = 1
+	}
+	w, h := opts.Width*opts.Supersample, opts.Height*opts.Supersample
+	m := scene.Mesh
+	bounds := m.Bounds()
+	cam := newCamera(bounds, opts, w, h)
+	cut := math.Inf(1)
+	if opts.CutAbove > 0 {
+		cut = bounds.Min.Y + opts.CutAbove*(bounds.Max.Y-bounds.Min.Y)
+	}
+	// Light from above and behind the camera, slightly to its left. It is
+	// anchored to world up so steep views still light the walls facing them.
+	light := vec3{0, 0.8, 0}.sub(cam.forward.scale(0.6)).sub(cam.right.scale(0.35)).normalize()
+
+	depth := make([]float64, w*h) // 1/z of the nearest surface, 0 = empty
+	pixels := make([]vec3, w*h)
+
+	for _, tri := range m.Triangles {
+		if math.Min(m.Positions[tri.V[0].P].Y, math.Min(m.Positions[tri.V[1].P].Y, m.Positions[tri.V[2].P].Y)) > cut {
+			continue
+		}
+		var world [3]vec3
+		var sx, sy, invZ [3]float64
+		behind := false
+		for i, v := range tri.V {
+			world[i] = fromMesh(m.Positions[v.P])
+			x, y, z := cam.project(world[i])
+			if z <= 1e-9 {
+				behind = true
+				break
+			}
+			sx[i], sy[i], invZ[i] = x, y, 1/z
+		}
+		if behind {
+			continue
+		}
+
+		area := (sx[1]-sx[0])*(sy[2]-sy[0]) - (sx[2]-sx[0])*(sy[1]-sy[0])
+		if math.Abs(area) < 1e-12 {
+			continue
+		}
+
+		// Two-sided lighting: normals are turned towards the camera.
+		faceNormal := world[1].sub(world[0]).cross(world[2].sub(world[0])).normalize()
+		toCamera := cam.eye.sub(world[0])
+		if faceNormal.dot(toCamera) < 0 {
+			faceNormal = faceNormal.scale(-1)
+		}
+		smooth := true
+		var normals [3]vec3
+		for i, v := range tri.V {
+			if v.N < 0 {
+				smooth = false
+				break
+			}
+			n := fromMesh(m.Normals[v.N])
+			if n.dot(faceNormal) < 0 {
+				n = n.scale(-1)
+			}
+			normals[i] = n
+		}
+		hasUV := true
+		var uvs [3][2]float64
+		for i, v := range tri.V {
+			if v.T < 0 {
+				hasUV = false
+				break
+			}
+			uvs[i] = [2]float64{m.UVs[v.T].U, m.UVs[v.T].V}
+		}
+		base, tex := scene.surface(tri.Material)
+
+		minX := int(math.Max(0, math.Floor(math.Min(sx[0], math.Min(sx[1], sx[2])))))
+		maxX := int(math.Min(float64(w-1), math.Ceil(math.Max(sx[0], math.Max(sx[1], sx[2])))))
+		minY := int(math.Max(0, math.Floor(math.Min(sy[0], math.Min(sy[1], sy[2])))))
+		maxY := int(math.Min(float64(h-1), math.Ceil(math.Max(sy[0], math.Max(sy[1], sy[2])))))
+
+		for py := minY; py <= maxY; py++ {
+			for px := minX; px <= maxX; px++ {
+				x, y := float64(px)+0.5, float64(py)+0.5
+				b0 := ((sx[1]-x)*(sy[2]-y) - (sx[2]-x)*(sy[1]-y)) / area
+				b1 := ((sx[2]-x)*(sy[0]-y) - (sx[0]-x)*(sy[2]-y)) / area
+				b2 := 1 - b0 - b1
+				if b0 < 0 || b1 < 0 || b2 < 0 {
+					continue
+				}
+				iz := b0*invZ[0] + b1*invZ[1] + b2*invZ[2]
+				idx := py*w + px
+				if iz <= depth[idx] {
+					continue
+				}
+				depth[idx] = iz
+
+				// Perspective-correct weights.
+				p0, p1, p2 := b0*invZ[0]/iz, b1*invZ[1]/iz, b2*invZ[2]/iz
+				n := faceNormal
+				if smooth {
+					n = normals[0].scale(p0).add(normals[1].scale(p1)).add(normals[2].scale(p2)).normalize()
+				}
+				col := base
+				if tex != nil && hasUV {
+					u := p0*uvs[0][0] + p1*uvs[1][0] + p2*uvs[2][0]
+					v := p0*uvs[0][1] + p1*uvs[1][1] + p2*uvs[2][1]
+					col = sample(tex, u, v)
+				}
+				shade := 0.35 + 0.65*math.Max(0, n.dot(light))
+				pixels[idx] = col.scale(shade)
+			}
+		}
+	}
+
+	return downsample(pixels, depth, w, h, opts.Supersample)
+}
+
+// surface returns the base colour and texture of a material.
+func (s Scene) surface(material string) (vec3, image.Image) {
+	if s.Texture != nil {
+		return vec3{1, 1, 1}, s.Texture
+	}
+	mat, ok := s.Materials[material]
+	if !ok {
+		return vec3{0.8, 0.8, 0.8}, nil
+	}
+	base := vec3{mat.Diffuse[0], mat.Diffuse[1], mat.Diffuse[2]}
+	if tex, ok := s.Textures[mat.DiffuseMap]; ok && mat.DiffuseMap != "" {
+		return base, tex
+	}
+	return base, nil
+}
+
+// sample reads a texture at OBJ texture coordinates, repeating outside 0..1.
+func sample(img image.Image, u, v float64) vec3 {
+	b := img.Bounds()
+	u -= math.Floor(u)
+	v -= math.Floor(v)
+	x := b.Min.X + int(u*float64(b.Dx()))
+	y := b.Min.Y + int((1-v)*float64(b.Dy()))
+	if x >= b.Max.X {
+		x = b.Max.X - 1
+	}
+	if y >= b.Max.Y {
+		y = b.Max.Y - 1
+	}
+	c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	return vec3{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}
+}
+
+// downsample averages factor x factor blocks; uncovered samples are
+// transparent.
+func downsample(pixels []vec3, depth []float64, w, h, factor int) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, w/factor, h/factor))
+	samples := float64(factor * factor)
+	for oy := 0; oy < h/factor; oy++ {
+		for ox := 0; ox < w/factor; ox++ {
+			var sum vec3
+			covered := 0.0
+			for dy := 0; dy < factor; dy++ {
+				for dx := 0; dx < factor; dx++ {
+					idx := (oy*factor+dy)*w + ox*factor + dx
+					if depth[idx] > 0 {
+						sum = sum.add(pixels[idx])
+						covered++
+					}
+				}
+			}
+			if covered == 0 {
+				continue
+			}
+			c := sum.scale(1 / covered)
+			out.SetNRGBA(ox, oy, color.NRGBA{
+				R: channel(c.x), G: channel(c.y), B: channel(c.z),
+				A: uint8(math.Round(covered / samples * 255)),
+			})
+		}
+	}
+	return out
+}
+
+func channel(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
@@ -8,6 +8,10 @@ import (
 )
 
 func SetupRoutes(router *gin.Engine) {
+	// Asset files, reachable only through signed URLs
+	router.GET("/assets/*key", handlers.ServeAssetFile)
+	router.HEAD("/assets/*key", handlers.ServeAssetFile)
+
 	api := router.Group("/api")
 	{
 		// Public routes
@@ -19,18 +23,48 @@ func SetupRoutes(router *gin.Engine) {
 		protected.Use(middleware.AuthMiddleware())
 		{
 			protected.GET("/users", handlers.GetUsers)
-			protected.GET("/projects/:username", handlers.GetProjectsByUser)
+			protected.GET("/projects/:id", handlers.GetProjectsByUser) // lists the authenticated user's projects; :id is ignored
+			protected.GET("/projects/:id/bom", handlers.GetProjectBOM)
+			protected.GET("/projects/:id/budget", handlers.GetProjectBudget)
+			protected.GET("/projects/:id/plan.svg", handlers.GetProjectPlanSVG)
+			protected.GET("/projects/:id/plan.pdf", handlers.GetProjectPlanPDF)
+			protected.GET("/projects/:id/scene.glb", handlers.GetProjectSceneGLB)
+			protected.GET("/projects/:id/archive", handlers.ExportProjectArchive)
+			protected.POST("/projects/:id/snap", handlers.SnapPlacement)
+			protected.PUT("/projects/:id/budget", handlers.UpdateProjectBudget)
 			protected.GET("/projects_id/:id", handlers.GetProjectByID) // <-- New route for fetching a project by ID
 			protected.POST("/projects", handlers.CreateProject)
+			protected.POST("/projects/import", handlers.ImportProjectArchive)
 
 			// Furniture routes
 			protected.GET("/users/projects/:projectId/furniture", handlers.GetPlacedFurnitureByProject)
 			protected.POST("/furniture", handlers.AddPlacedFurniture)
 			protected.PUT("/furniture/:id", handlers.UpdateFurniturePosition)
 			protected.DELETE("/furniture/delete/:id", handlers.DeletePlacedFurniture)
+			protected.PUT("/furniture/:id/variant", handlers.UpdatePlacedFurnitureVariant) // :id is the placed furniture ID
+
+			// Catalog routes
+			protected.POST("/furniture/upload", handlers.UploadFurniture)
+			protected.POST("/furniture/import", handlers.ImportFurniture)
+			protected.PUT("/furniture/:id/dimensions", handlers.UpdateFurnitureDimensions)
+			protected.PUT("/furniture/:id/classification", handlers.UpdateFurnitureClassification)
+			protected.PUT("/furniture/:id/pricing", handlers.UpdateFurniturePricing)
+			protected.POST("/furniture/:id/variants", handlers.CreateFurnitureVariant)
+			protected.DELETE("/furniture/:id/variants/:variantId", handlers.DeleteFurnitureVariant)
+			protected.POST("/rooms", middleware.AdminMiddleware(), handlers.CreateRoom)
+			protected.POST("/rooms/generate", handlers.GenerateRoom)
+			protected.POST("/rooms/import", handlers.ImportFloorPlan)
+			protected.GET("/users/rooms", handlers.GetUserRooms)
+			protected.PUT("/rooms/:id", handlers.UpdateRoom)
+			protected.DELETE("/rooms/:id", handlers.DeleteRoom)
+			protected.PUT("/rooms/:id/dimensions", handlers.UpdateRoomDimensions)
+			protected.PUT("/rooms/:id/openings", handlers.UpdateRoomOpenings)
 		}
 
 		api.GET("/furniture/all", handlers.GetAllFurniture)
+		api.GET("/furniture/search", handlers.SearchFurniture)
+		api.GET("/furniture/categories", handlers.GetFurnitureCategories)
+		api.GET("/furniture/:id/variants", handlers.GetFurnitureVariants)
 		api.GET("/rooms", handlers.GetAllRooms)
 		api.GET("/rooms/:id", handlers.GetRoomByID)
 		api.GET("/assets/:id", handlers.GetAssetByID)
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores assets in a directory served by the HTTP server. With a
+// Signer, URLs expire and the server only streams files for valid ones.
+type Local struct {
+	Root    string
+	BaseURL string
+	Signer  *Signer
+}
+
+// NewLocal returns a Local storage rooted at root and served at baseURL.
+func NewLocal(root, baseURL string, signer *Signer) *Local {
+	return &Local{Root: root, BaseURL: strings.TrimRight(baseURL, "/"), Signer: signer}
+}
+
+func (l *Local) String() string {
+	return "local filesystem at " + l.Root
+}
+
+// path maps a key to a file under Root.
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Root, filepath.FromSlash(NormalizeKey(key)))
+}
+
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	dest := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Exists(_ context.Context, key string) (bool, error) {
+	_, err := os.Stat(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *Local) URL(key string) string {
+	u := l.BaseURL + "/" + escapeKey(key)
+	if l.Signer != nil {
+		u += "?" + l.Signer.Query(key, time.Now()).Encode()
+	}
+	return u
+}
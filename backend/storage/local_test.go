@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	l := NewLocal(root, "http://localhost:8080/assets/", nil)
+
+	if err := l.Put(ctx, "furniture/1/chair.obj", strings.NewReader("v 0 0 0\n"), -1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "furniture", "1", "chair.obj")); err != nil {
+		t.Errorf("file not stored under the root: %v", err)
+	}
+	for _, key := range []string{"furniture/1/chair.obj", "assets/objects/furniture/1/chair.obj", "/furniture//1/chair.obj"} {
+		ok, err := l.Exists(ctx, key)
+		if err != nil || !ok {
+			t.Errorf("Exists(%q) = %v, %v; want true", key, ok, err)
+		}
+	}
+	f, err := l.Open(ctx, "furniture/1/chair.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "v 0 0 0\n" {
+		t.Errorf("Open read %q", data)
+	}
+
+	// Keys cannot climb out of the root.
+	if err := l.Put(ctx, "../outside.txt", strings.NewReader("x"), -1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside.txt")); err != nil {
+		t.Errorf("escaping key not kept under the root: %v", err)
+	}
+
+	if err := l.Delete(ctx, "furniture/1/chair.obj"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := l.Exists(ctx, "furniture/1/chair.obj"); ok || err != nil {
+		t.Errorf("Exists after Delete = %v, %v", ok, err)
+	}
+	if _, err := l.Open(ctx, "furniture/1/chair.obj"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "furniture/1/chair.obj"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "plain", key: "furniture/1/chair.obj", want: "http://localhost:8080/assets/furniture/1/chair.obj"},
+		{name: "legacy path", key: "assets/objects/rooms/2/room.obj", want: "http://localhost:8080/assets/rooms/2/room.obj"},
+		{name: "escaped", key: "furniture/1/arm chair#2.obj", want: "http://localhost:8080/assets/furniture/1/arm%20chair%232.obj"},
+	}
+	l := NewLocal(t.TempDir(), "http://localhost:8080/assets/", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.URL(tt.key); got != tt.want {
+				t.Errorf("URL(%q) = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	signer := &Signer{Secret: []byte("secret"), TTL: time.Hour}
+	l.Signer = signer
+	u, err := url.Parse(l.URL("furniture/1/chair.obj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if err := signer.Verify("furniture/1/chair.obj", q.Get("expires"), q.Get("sig"), time.Now()); err != nil {
+		t.Errorf("signed URL does not verify: %v", err)
+	}
+}
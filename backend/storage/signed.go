@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by Signer.Verify.
+var (
+	ErrURLExpired   = errors.New("storage: asset URL has expired")
+	ErrURLSignature = errors.New("storage: invalid asset URL signature")
+)
+
+// Signer issues and verifies time-limited asset URLs. A URL carries its
+// expiry time and an HMAC-SHA256 of the key and expiry, so it cannot be
+// altered to reach another file or to live longer.
+type Signer struct {
+	Secret []byte
+	// TTL is the longest a URL stays valid. Expiry times are rounded to half
+	// the TTL so URLs stay identical, and cacheable, for a while.
+	TTL time.Duration
+}
+
+// Expiry returns the expiry time of URLs issued at now: between TTL/2 and TTL
+// in the future.
+func (s *Signer) Expiry(now time.Time) time.Time {
+	window := int64(s.TTL / 2 / time.Second)
+	if window < 1 {
+		window = 1
+	}
+	return time.Unix((now.Unix()/window+2)*window, 0)
+}
+
+// Sign returns the signature of key for the given expiry.
+func (s *Signer) Sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(NormalizeKey(key) + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Query returns the "expires" and "sig" query parameters for key.
+func (s *Signer) Query(key string, now time.Time) url.Values {
+	expires := s.Expiry(now).Unix()
+	return url.Values{
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sig":     {s.Sign(key, expires)},
+	}
+}
+
+// Verify checks the expires and sig query parameters of a request for key.
+func (s *Signer) Verify(key, expires, sig string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || sig == "" {
+		return ErrURLSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.Sign(key, exp))) {
+		return ErrURLSignature
+	}
+	if now.Unix() > exp {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+// escapeKey percent-encodes each segment of a key for use in a URL path.
+func escapeKey(key string) string {
+	segments := strings.Split(NormalizeKey(key), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
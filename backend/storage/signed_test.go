@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	s := &Signer{Secret: []byte("secret"), TTL: time.Hour}
+	now := time.Unix(1700000000, 0)
+	query := s.Query("furniture/1/chair.obj", now)
+	expires, sig := query.Get("expires"), query.Get("sig")
+	exp, _ := strconv.ParseInt(expires, 10, 64)
+
+	tests := []struct {
+		name    string
+		key     string
+		expires string
+		sig     string
+		now     time.Time
+		want    error
+	}{
+		{name: "valid", key: "furniture/1/chair.obj", expires: expires, sig: sig, now: now},
+		{name: "at expiry", key: "furniture/1/chair.obj", expires: expires, sig: sig, now: time.Unix(exp, 0)},
+		{name: "expired", key: "furniture/1/chair.obj", expires: expires, sig: sig, now: time.Unix(exp+1, 0), want: ErrURLExpired},
+		{name: "other key", key: "furniture/2/chair.obj", expires: expires, sig: sig, now: now, want: ErrURLSignature},
+		{name: "extended expiry", key: "furniture/1/chair.obj", expires: strconv.FormatInt(exp+3600, 10), sig: sig, now: now, want: ErrURLSignature},
+		{name: "tampered signature", key: "furniture/1/chair.obj", expires: expires, sig: sig[:len(sig)-1] + "A", now: now, want: ErrURLSignature},
+		{name: "no signature", key: "furniture/1/chair.obj", expires: expires, now: now, want: ErrURLSignature},
+		{name: "bad expiry", key: "furniture/1/chair.obj", expires: "soon", sig: sig, now: now, want: ErrURLSignature},
+		{name: "legacy path", key: "assets/objects/furniture/1/chair.obj", expires: expires, sig: sig, now: now},
+		{name: "unnormalised key", key: "/furniture/./1//chair.obj", expires: expires, sig: sig, now: now},
+		{name: "escaping key", key: "../furniture/1/chair.obj", expires: expires, sig: sig, now: now},
+		{name: "other secret", key: "furniture/1/chair.obj", expires: expires, sig: (&Signer{Secret: []byte("other")}).Sign("furniture/1/chair.obj", exp), now: now, want: ErrURLSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Verify(tt.key, tt.expires, tt.sig, tt.now); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerExpiry(t *testing.T) {
+	s := &Signer{Secret: []byte("secret"), TTL: time.Hour}
+	start := time.Unix(1800*1000000, 0)
+	for _, offset := range []time.Duration{0, time.Minute, 29 * time.Minute} {
+		if got := s.Expiry(start.Add(offset)); !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("Expiry(start+%v) = %v, want %v", offset, got, start.Add(time.Hour))
+		}
+	}
+	for _, offset := range []time.Duration{0, 17 * time.Minute, 29 * time.Minute} {
+		now := time.Unix(1700000000, 0).Add(offset)
+		left := s.Expiry(now).Sub(now)
+		if left < 30*time.Minute || left > time.Hour {
+			t.Errorf("Expiry(now) is %v ahead, want between 30m and 1h", left)
+		}
+	}
+}
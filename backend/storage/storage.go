@@ -0,0 +1,129 @@
+// Package storage abstracts where asset files (models, textures, thumbnails)
+// live. The database stores backend-neutral keys such as
+// "furniture/123/chair.obj"; URLs are resolved from keys at response time.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores asset files by key.
+type Storage interface {
+	// Put stores r under key. size may be -1 when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the contents of key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Exists reports whether key is stored.
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL returns the address clients download key from.
+	URL(key string) string
+}
+
+// Assets is the storage backend used by the handlers. It is set by Init.
+var Assets Storage
+
+// legacyPrefix is the directory older rows stored in front of every path.
+const legacyPrefix = "assets/objects/"
+
+// NormalizeKey turns a stored path into a key: forward slashes, no leading
+// "./" or "/", and without the legacy "assets/objects/" prefix. Keys that
+// would escape the storage root are cleaned.
+func NormalizeKey(p string) string {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" {
+		return ""
+	}
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	return strings.TrimPrefix(p, legacyPrefix)
+}
+
+// URLFor resolves a stored path to a client URL, or "" for an empty path.
+func URLFor(p string) string {
+	key := NormalizeKey(p)
+	if key == "" || Assets == nil {
+		return key
+	}
+	return Assets.URL(key)
+}
+
+// Init configures Assets from the environment:
+//
+//	STORAGE_BACKEND  local (default) or s3
+//	ASSET_ROOT       local directory, default ./assets/objects
+//	ASSET_BASE_URL   public URL of the local directory, default http://localhost:8080/assets
+//	ASSET_URL_SECRET key signing local asset URLs; random per process if unset
+//	ASSET_URL_TTL    lifetime of signed asset URLs, default 1h
+//	S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY,
+//	S3_PUBLIC_URL, S3_PATH_STYLE (true for MinIO)
+//
+// S3 objects are linked with presigned URLs unless S3_PUBLIC_URL is set.
+// The environment is loaded by main.
+func Init() {
+	var err error
+	Assets, err = FromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure asset storage:", err)
+	}
+	log.Printf("Asset storage configured: %v", Assets)
+}
+
+// FromEnv builds a Storage from the environment variables listed on Init.
+func FromEnv() (Storage, error) {
+	ttl := time.Hour
+	if raw := os.Getenv("ASSET_URL_TTL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid ASSET_URL_TTL %q", raw)
+		}
+		ttl = parsed
+	}
+
+	switch backend := strings.ToLower(os.Getenv("STORAGE_BACKEND")); backend {
+	case "", "local":
+		root := os.Getenv("ASSET_ROOT")
+		if root == "" {
+			root = "./assets/objects"
+		}
+		baseURL := os.Getenv("ASSET_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080/assets"
+		}
+		secret := []byte(os.Getenv("ASSET_URL_SECRET"))
+		if len(secret) == 0 {
+			log.Println("Warning: ASSET_URL_SECRET is not set, asset URLs will not survive a restart")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+		return NewLocal(root, baseURL, &Signer{Secret: secret, TTL: ttl}), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+			URLTTL:    ttl,
+		}
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+}
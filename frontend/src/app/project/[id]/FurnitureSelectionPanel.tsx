@@ -49,7 +49,11 @@ const FurnitureSelectionPanel: React.FC<FurnitureSelectionPanelProps> = ({
                             <div className="w-full h-28 mb-2 bg-gray-600/50 rounded-lg overflow-hidden flex items-center justify-center">
                                 {furniture.thumbnail_path ? (
                                     <img
-                                        src={`http://localhost:8080/${furniture.thumbnail_path.replace('objects/', '')}`}
+                                        src={
+                                            furniture.thumbnail_path.startsWith('http')
+                                                ? furniture.thumbnail_path
+                                                : `http://localhost:8080/${furniture.thumbnail_path.replace('objects/', '')}`
+                                        }
                                         alt={furniture.name}
                                         className="w-full h-full object-contain"
                                         onError={(e) => {
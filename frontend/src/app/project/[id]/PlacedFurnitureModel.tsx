@@ -21,9 +21,13 @@ const PlacedFurnitureModel: React.FC<PlacedFurnitureModelProps> = ({
 
     if (!furniture || !furniture.obj_file_path) return null;
 
-    const objUrl = `http://localhost:8080/${furniture.obj_file_path.replace('objects/', '')}`;
+    const objUrl = furniture.obj_file_path.startsWith('http')
+        ? furniture.obj_file_path
+        : `http://localhost:8080/${furniture.obj_file_path.replace('objects/', '')}`;
     const textureUrl = furniture.texture_path
-        ? `http://localhost:8080/${furniture.texture_path.replace('objects/', '')}`
+        ? (furniture.texture_path.startsWith('http')
+            ? furniture.texture_path
+            : `http://localhost:8080/${furniture.texture_path.replace('objects/', '')}`)
         : '';
 
     return (
@@ -54,7 +54,11 @@ const PlacedFurniturePanel: React.FC<PlacedFurniturePanelProps> = ({
                                     <div className="w-14 h-14 mr-3 bg-gray-600/50 rounded-lg overflow-hidden flex items-center justify-center">
                                         {placedObject.furniture.thumbnail_path ? (
                                             <img
-                                                src={`http://localhost:8080/${placedObject.furniture.thumbnail_path.replace('objects/', '')}`}
+                                                src={
+                                                    placedObject.furniture.thumbnail_path.startsWith('http')
+                                                        ? placedObject.furniture.thumbnail_path
+                                                        : `http://localhost:8080/${placedObject.furniture.thumbnail_path.replace('objects/', '')}`
+                                                }
                                                 alt={placedObject.furniture.name}
                                                 className="w-full h-full object-contain"
                                                 onError={(e) => {
@@ -0,0 +1,12 @@
+--
+-- Model metadata extracted from the OBJ/MTL files when a furniture item is uploaded.
+--
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS vertex_count integer DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS face_count integer DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS bounds_width double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS bounds_height double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS bounds_depth double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS texture_files text[] DEFAULT '{}'::text[] NOT NULL,
+    ADD COLUMN IF NOT EXISTS units character varying(16) DEFAULT 'm'::character varying NOT NULL;
@@ -0,0 +1,29 @@
+--
+-- Real-world dimensions in metres for catalog items and rooms. Values come from
+-- the model bounds (dimensions_source = 'model') or are entered by hand ('manual').
+--
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS width double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS depth double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS height double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS dimensions_source character varying(16) DEFAULT 'model'::character varying NOT NULL;
+
+ALTER TABLE public.room
+    ADD COLUMN IF NOT EXISTS width double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS depth double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS ceiling_height double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS floor_area double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS dimensions_source character varying(16) DEFAULT 'model'::character varying NOT NULL;
+
+-- Convert the recorded model bounds for existing catalog items.
+UPDATE public.furniture
+SET width = bounds_width * s.scale,
+    depth = bounds_depth * s.scale,
+    height = bounds_height * s.scale
+FROM (VALUES ('m', 1.0), ('cm', 0.01), ('mm', 0.001), ('in', 0.0254), ('ft', 0.3048)) AS s(units, scale)
+WHERE furniture.units = s.units
+  AND furniture.dimensions_source = 'model';
+
+CREATE INDEX IF NOT EXISTS furniture_dimensions_idx ON public.furniture USING btree (width, depth, height);
+CREATE INDEX IF NOT EXISTS room_floor_area_idx ON public.room USING btree (floor_area);
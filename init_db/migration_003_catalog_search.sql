@@ -0,0 +1,46 @@
+--
+-- Catalog categories, free-form tags and the indexes behind /api/furniture/search.
+--
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS public.furniture_category (
+    slug character varying(32) NOT NULL,
+    name character varying(64) NOT NULL,
+    CONSTRAINT furniture_category_pkey PRIMARY KEY (slug)
+);
+
+ALTER TABLE public.furniture_category OWNER TO postgres;
+
+INSERT INTO public.furniture_category (slug, name) VALUES
+    ('seating', 'Seating'),
+    ('tables', 'Tables'),
+    ('storage', 'Storage'),
+    ('beds', 'Beds'),
+    ('lighting', 'Lighting'),
+    ('decor', 'Decor'),
+    ('kitchen', 'Kitchen'),
+    ('bathroom', 'Bathroom'),
+    ('office', 'Office'),
+    ('outdoor', 'Outdoor')
+ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS category character varying(32),
+    ADD COLUMN IF NOT EXISTS tags text[] DEFAULT '{}'::text[] NOT NULL;
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple'::regconfig, COALESCE(name, '')), 'A') ||
+        setweight(array_to_tsvector(tags), 'B')
+    ) STORED;
+
+ALTER TABLE ONLY public.furniture
+    DROP CONSTRAINT IF EXISTS furniture_category_fkey;
+ALTER TABLE ONLY public.furniture
+    ADD CONSTRAINT furniture_category_fkey FOREIGN KEY (category) REFERENCES public.furniture_category(slug) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS furniture_search_vector_idx ON public.furniture USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS furniture_name_trgm_idx ON public.furniture USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS furniture_tags_idx ON public.furniture USING gin (tags);
+CREATE INDEX IF NOT EXISTS furniture_category_idx ON public.furniture USING btree (category);
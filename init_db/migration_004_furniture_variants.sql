@@ -0,0 +1,31 @@
+--
+-- Material/colour/finish variants sharing a catalog item's geometry, and the
+-- variant chosen for each placement (NULL keeps the item's default texture).
+--
+
+CREATE TABLE IF NOT EXISTS public.furniture_variant (
+    id serial NOT NULL,
+    furniture_id integer NOT NULL,
+    name character varying(255) NOT NULL,
+    material character varying(64) DEFAULT ''::character varying NOT NULL,
+    color character varying(64) DEFAULT ''::character varying NOT NULL,
+    finish character varying(64) DEFAULT ''::character varying NOT NULL,
+    texture_path text DEFAULT ''::text NOT NULL,
+    mtl_file_path text DEFAULT ''::text NOT NULL,
+    thumbnail_path text DEFAULT ''::text NOT NULL,
+    texture_files text[] DEFAULT '{}'::text[] NOT NULL,
+    CONSTRAINT furniture_variant_pkey PRIMARY KEY (id),
+    CONSTRAINT furniture_variant_furniture_id_fkey FOREIGN KEY (furniture_id) REFERENCES public.furniture(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE public.furniture_variant OWNER TO postgres;
+
+CREATE INDEX IF NOT EXISTS furniture_variant_furniture_id_idx ON public.furniture_variant USING btree (furniture_id);
+
+ALTER TABLE public."PlacedFurniture"
+    ADD COLUMN IF NOT EXISTS variant_id integer;
+
+ALTER TABLE ONLY public."PlacedFurniture"
+    DROP CONSTRAINT IF EXISTS "PlacedFurniture_variant_id_fkey";
+ALTER TABLE ONLY public."PlacedFurniture"
+    ADD CONSTRAINT "PlacedFurniture_variant_id_fkey" FOREIGN KEY (variant_id) REFERENCES public.furniture_variant(id) ON UPDATE CASCADE ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+--
+-- Catalog pricing and supplier details used for project bills of materials.
+--
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS price numeric(12,2) DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS currency character(3) DEFAULT 'EUR'::bpchar NOT NULL,
+    ADD COLUMN IF NOT EXISTS sku character varying(64),
+    ADD COLUMN IF NOT EXISTS supplier character varying(255) DEFAULT ''::character varying NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS furniture_sku_key ON public.furniture USING btree (sku);
@@ -0,0 +1,7 @@
+--
+-- Optional per-project budget, compared against catalog prices of placed furniture.
+--
+
+ALTER TABLE public.projects
+    ADD COLUMN IF NOT EXISTS budget numeric(12,2),
+    ADD COLUMN IF NOT EXISTS budget_currency character(3) DEFAULT 'EUR'::bpchar NOT NULL;
@@ -0,0 +1,19 @@
+--
+-- Asset paths become backend-neutral storage keys: forward slashes and no
+-- leading "assets/objects/" directory. URLs are resolved by the server.
+--
+
+UPDATE public.furniture SET
+    obj_file_path = regexp_replace(replace(obj_file_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    texture_path = regexp_replace(replace(texture_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    thumbnail_path = regexp_replace(replace(thumbnail_path, '\', '/'), '^(\./)?assets/objects/', '');
+
+UPDATE public.room SET
+    obj_file_path = regexp_replace(replace(obj_file_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    texture_path = regexp_replace(replace(texture_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    thumbnail_path = regexp_replace(replace(thumbnail_path, '\', '/'), '^(\./)?assets/objects/', '');
+
+UPDATE public.furniture_variant SET
+    texture_path = regexp_replace(replace(texture_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    mtl_file_path = regexp_replace(replace(mtl_file_path, '\', '/'), '^(\./)?assets/objects/', ''),
+    thumbnail_path = regexp_replace(replace(thumbnail_path, '\', '/'), '^(\./)?assets/objects/', '');
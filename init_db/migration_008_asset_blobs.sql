@@ -0,0 +1,66 @@
+--
+-- Content-addressed asset files. Each blob is stored once under its SHA-256
+-- and counted by the furniture, room and variant rows referencing it.
+-- Files written before this migration are not tracked and never collected.
+--
+
+CREATE TABLE IF NOT EXISTS public.asset_blob (
+    hash character(64) PRIMARY KEY,
+    key text NOT NULL,
+    size bigint NOT NULL,
+    content_type text DEFAULT ''::text NOT NULL,
+    ref_count integer DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS asset_blob_orphan_idx ON public.asset_blob (created_at) WHERE ref_count <= 0;
+
+CREATE TABLE IF NOT EXISTS public.asset_ref (
+    owner_type text NOT NULL CHECK (owner_type IN ('furniture', 'room', 'furniture_variant')),
+    owner_id integer NOT NULL,
+    name text NOT NULL,
+    hash character(64) NOT NULL REFERENCES public.asset_blob(hash),
+    PRIMARY KEY (owner_type, owner_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS asset_ref_hash_idx ON public.asset_ref (hash);
+
+ALTER TABLE public.asset_blob OWNER TO postgres;
+ALTER TABLE public.asset_ref OWNER TO postgres;
+
+-- Keep asset_blob.ref_count in step with asset_ref.
+CREATE OR REPLACE FUNCTION public.asset_ref_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE public.asset_blob SET ref_count = ref_count + 1 WHERE hash = NEW.hash;
+    END IF;
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        UPDATE public.asset_blob SET ref_count = ref_count - 1, created_at = now() WHERE hash = OLD.hash;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS asset_ref_count ON public.asset_ref;
+CREATE TRIGGER asset_ref_count AFTER INSERT OR UPDATE OF hash OR DELETE ON public.asset_ref
+    FOR EACH ROW EXECUTE FUNCTION public.asset_ref_count();
+
+-- Deleting an owner row releases its references.
+CREATE OR REPLACE FUNCTION public.asset_ref_release() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM public.asset_ref WHERE owner_type = TG_ARGV[0] AND owner_id = OLD.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS furniture_asset_release ON public.furniture;
+CREATE TRIGGER furniture_asset_release AFTER DELETE ON public.furniture
+    FOR EACH ROW EXECUTE FUNCTION public.asset_ref_release('furniture');
+
+DROP TRIGGER IF EXISTS room_asset_release ON public.room;
+CREATE TRIGGER room_asset_release AFTER DELETE ON public.room
+    FOR EACH ROW EXECUTE FUNCTION public.asset_ref_release('room');
+
+DROP TRIGGER IF EXISTS furniture_variant_asset_release ON public.furniture_variant;
+CREATE TRIGGER furniture_variant_asset_release AFTER DELETE ON public.furniture_variant
+    FOR EACH ROW EXECUTE FUNCTION public.asset_ref_release('furniture_variant');
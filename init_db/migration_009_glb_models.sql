@@ -0,0 +1,10 @@
+--
+-- Binary glTF conversions of the OBJ models, produced on upload or by the
+-- "convert" backfill command. Empty until a conversion has run.
+--
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS glb_file_path text DEFAULT ''::text NOT NULL;
+
+ALTER TABLE public.room
+    ADD COLUMN IF NOT EXISTS glb_file_path text DEFAULT ''::text NOT NULL;
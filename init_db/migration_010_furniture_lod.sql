@@ -0,0 +1,15 @@
+--
+-- Simplified GLB levels of detail of heavy catalog models. Level 1 is the
+-- most detailed; clients switch to a level beyond its min_distance (metres).
+--
+
+CREATE TABLE IF NOT EXISTS public.furniture_lod (
+    furniture_id integer NOT NULL REFERENCES public.furniture(id) ON DELETE CASCADE,
+    level integer NOT NULL CHECK (level > 0),
+    face_count integer NOT NULL,
+    glb_file_path text NOT NULL,
+    min_distance double precision NOT NULL,
+    PRIMARY KEY (furniture_id, level)
+);
+
+ALTER TABLE public.furniture_lod OWNER TO postgres;
@@ -0,0 +1,16 @@
+--
+-- Rendered previews of projects (room plus placed furniture), regenerated
+-- when the project is saved. Empty until the first render.
+--
+
+ALTER TABLE public.projects
+    ADD COLUMN IF NOT EXISTS preview_path text DEFAULT ''::text NOT NULL;
+
+-- Previews are content-addressed blobs owned by the project.
+ALTER TABLE public.asset_ref DROP CONSTRAINT IF EXISTS asset_ref_owner_type_check;
+ALTER TABLE public.asset_ref ADD CONSTRAINT asset_ref_owner_type_check
+    CHECK (owner_type IN ('furniture', 'room', 'furniture_variant', 'project'));
+
+DROP TRIGGER IF EXISTS projects_asset_release ON public.projects;
+CREATE TRIGGER projects_asset_release AFTER DELETE ON public.projects
+    FOR EACH ROW EXECUTE FUNCTION public.asset_ref_release('project');
@@ -0,0 +1,10 @@
+--
+-- Rooms used by projects can no longer be deleted out from under them; the
+-- room API moves the projects to another room first. Previously the
+-- projects silently lost their room.
+--
+
+ALTER TABLE public.projects DROP CONSTRAINT IF EXISTS projects_room_layout_id_fkey;
+ALTER TABLE public.projects
+    ADD CONSTRAINT projects_room_layout_id_fkey FOREIGN KEY (room_layout_id)
+    REFERENCES public.room(id) ON UPDATE CASCADE ON DELETE RESTRICT;
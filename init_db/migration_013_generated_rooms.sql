@@ -0,0 +1,11 @@
+--
+-- Rooms generated by users from a floor plan. owner_id is NULL for catalog
+-- rooms; floor_plan keeps the outline, wall height and thickness the model
+-- was built from.
+--
+
+ALTER TABLE public.room
+    ADD COLUMN IF NOT EXISTS owner_id integer REFERENCES public.users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS floor_plan jsonb;
+
+CREATE INDEX IF NOT EXISTS room_owner_id_idx ON public.room USING btree (owner_id) WHERE owner_id IS NOT NULL;
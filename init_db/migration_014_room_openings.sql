@@ -0,0 +1,21 @@
+--
+-- Doors, windows and plain openings in room walls. wall indexes the walls of
+-- the room's floor plan (or of its width x depth rectangle for catalog rooms
+-- without one); sizes are in metres.
+--
+
+CREATE TABLE IF NOT EXISTS public.room_opening (
+    id serial PRIMARY KEY,
+    room_id integer NOT NULL REFERENCES public.room(id) ON DELETE CASCADE,
+    type character varying(16) NOT NULL CHECK (type IN ('door', 'window', 'opening')),
+    wall integer NOT NULL CHECK (wall >= 0),
+    "offset" double precision NOT NULL CHECK ("offset" >= 0),
+    width double precision NOT NULL CHECK (width > 0),
+    height double precision NOT NULL CHECK (height > 0),
+    sill_height double precision DEFAULT 0 NOT NULL CHECK (sill_height >= 0),
+    swing character varying(8) DEFAULT ''::character varying NOT NULL CHECK (swing IN ('', 'left', 'right'))
+);
+
+CREATE INDEX IF NOT EXISTS room_opening_room_id_idx ON public.room_opening USING btree (room_id);
+
+ALTER TABLE public.room_opening OWNER TO postgres;
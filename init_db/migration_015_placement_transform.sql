@@ -0,0 +1,19 @@
+--
+-- Full transforms for placed furniture. rotation is the turn about the
+-- vertical axis the editor has always sent, missing from the original
+-- schema; rotation_x and rotation_z complete it as XYZ Euler angles in
+-- radians, and scale_x/y/z stretch the model along its own axes.
+--
+
+ALTER TABLE public."PlacedFurniture" ADD COLUMN IF NOT EXISTS rotation double precision;
+
+UPDATE public."PlacedFurniture" SET rotation = 0 WHERE rotation IS NULL;
+
+ALTER TABLE public."PlacedFurniture"
+    ALTER COLUMN rotation SET DEFAULT 0,
+    ALTER COLUMN rotation SET NOT NULL,
+    ADD COLUMN IF NOT EXISTS rotation_x double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS rotation_z double precision DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS scale_x double precision DEFAULT 1 NOT NULL CHECK (scale_x > 0),
+    ADD COLUMN IF NOT EXISTS scale_y double precision DEFAULT 1 NOT NULL CHECK (scale_y > 0),
+    ADD COLUMN IF NOT EXISTS scale_z double precision DEFAULT 1 NOT NULL CHECK (scale_z > 0);
@@ -0,0 +1,10 @@
+--
+-- Where a catalog room's floor lies in the editor, which loads the room
+-- model at its own coordinates: the lowest X and Z of the model bounds, in
+-- metres. NULL until the model is measured again; the room's bounds are then
+-- unknown and placements are not checked against them.
+--
+
+ALTER TABLE public.room
+    ADD COLUMN IF NOT EXISTS origin_x double precision,
+    ADD COLUMN IF NOT EXISTS origin_z double precision;
@@ -0,0 +1,18 @@
+--
+-- Rendered thumbnails are referenced as 'rendered/thumbnail.png', a name no
+-- uploaded file can have, so an upload called thumbnail.png is not taken for
+-- one. Existing references to the thumbnail an item or room shows are
+-- renamed, as they were treated as rendered before.
+--
+
+UPDATE public.asset_ref r
+SET name = 'rendered/thumbnail.png'
+FROM public.asset_blob b, public.room o
+WHERE r.owner_type = 'room' AND r.owner_id = o.id AND r.name = 'thumbnail.png'
+  AND r.hash = b.hash AND b.key = o.thumbnail_path;
+
+UPDATE public.asset_ref r
+SET name = 'rendered/thumbnail.png'
+FROM public.asset_blob b, public.furniture o
+WHERE r.owner_type = 'furniture' AND r.owner_id = o.id AND r.name = 'thumbnail.png'
+  AND r.hash = b.hash AND b.key = o.thumbnail_path;
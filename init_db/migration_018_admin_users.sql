@@ -0,0 +1,7 @@
+--
+-- Administrators manage the shared room catalog. Grant the role by hand:
+--     UPDATE public.users SET is_admin = true WHERE username = '...';
+--
+
+ALTER TABLE public.users
+    ADD COLUMN IF NOT EXISTS is_admin boolean DEFAULT false NOT NULL;
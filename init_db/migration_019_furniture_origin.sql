@@ -0,0 +1,11 @@
+--
+-- Where a catalog model lies around its own origin, which the editor places
+-- at the item's position: the lowest X, Y and Z of the model bounds, in
+-- metres. NULL until the model is measured again; the item is then taken to
+-- be centred on its position and standing on it.
+--
+
+ALTER TABLE public.furniture
+    ADD COLUMN IF NOT EXISTS origin_x double precision,
+    ADD COLUMN IF NOT EXISTS origin_y double precision,
+    ADD COLUMN IF NOT EXISTS origin_z double precision;